@@ -13,6 +13,8 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/prometheus/common/model"
+
 	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
 )
 
@@ -29,17 +31,33 @@ type queryParams struct {
 }
 
 const (
-	apiTimeout               = 3 * 60 * time.Second
-	scrapeIntervalQuery      = "scrape_interval(%s)"
-	systemIngestionRateQuery = ""
-	activeTimeSeriesQuery    = ""
-	systemChurnRateQuery     = "count(count_over_time(scrape_samples_scraped{}[1h]))"
-	metricResponseTimeTempl  = ""
+	apiTimeout              = 3 * 60 * time.Second
+	scrapeIntervalQuery     = "scrape_interval(%s)"
+	metricResponseTimeTempl = ""
+	// no. of points to collect for trend of system stats
+	systemTrendPoints = 30
+)
+
+const (
+	BackendVictoriaMetrics = "victoriametrics"
+	BackendPrometheus      = "prometheus"
 )
 
 const (
 	LabelCardinalityStr = "labelCardinality"
 	DuplicatesLabelsStr = "duplicateLabels"
+	LabelGroupCountStr  = "labelGroupCount"
+)
+
+// System stats are derived from self metrics of the backend
+const (
+	vmSystemChurnRateTempl        = `sum( rate( vm_new_timeseries_created_total[{{.Duration}}s] ) )`
+	vmSystemIngestionRateTempl    = `sum( rate( vm_rows_inserted_total[{{.Duration}}s] ) )`
+	vmSystemActiveTimeSeriesTempl = `sum( vm_cache_entries{type="storage/hour_metric_ids"} )`
+
+	promSystemChurnRateTempl        = `sum( rate( prometheus_tsdb_head_series_created_total[{{.Duration}}s] ) )`
+	promSystemIngestionRateTempl    = `sum( rate( prometheus_tsdb_head_samples_appended_total[{{.Duration}}s] ) )`
+	promSystemActiveTimeSeriesTempl = `sum( prometheus_tsdb_head_series )`
 )
 
 const labelCardinalityTempl = `
@@ -62,6 +80,17 @@ sum(  count without ( {{.LabelPair}} ) (
 )
 `
 
+const labelGroupCountTempl = `
+count (
+	group by ( {{.LabelPair}} ) (
+		count_over_time ( {{.Metric}}[{{.Duration}}s] )
+	)
+)
+`
+
+// Churn rate, ingestion rate, samples received and active timeseries count series
+// and samples without looking at their values, so the same query holds for every
+// metric type. Last loss and resets depend on values and take the type into account.
 const metricChurnRateTempl = `
 (
 	count( count_over_time( {{.Metric}}[{{.Duration}}s])  ) offset 1h 
@@ -101,8 +130,8 @@ tlast_change_over_time(
 
 {{ else }}
 
-timestamp(
-	sum({{.Metric}}[{{.Duration}}s])
+max(
+	tlast_over_time({{.Metric}}[{{.Duration}}s])
 )
 
 {{ end }}
@@ -148,6 +177,8 @@ func createQuery(params queryParams, templ string) (string, error) {
 		templ = labelCardinalityTempl
 	case DuplicatesLabelsStr:
 		templ = duplicateLabelsExistsTempl
+	case LabelGroupCountStr:
+		templ = labelGroupCountTempl
 	}
 
 	if t, err := LoadTmpl(templ); err != nil {
@@ -462,11 +493,13 @@ func ActiveTimeSeries(v1api v1.API, metric string, duration, offset int) (uint64
 	return strconv.ParseUint(values[0].Value, 10, 64)
 }
 
-func LastLoss(v1api v1.API, metric string, duration, offset int) (int, error) {
+// LastLoss returns the last time the metric changed for counters, and the last sample
+// for any other metric type
+func LastLoss(v1api v1.API, metric, metricType string, duration, offset int) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
 	defer cancel()
 
-	params := queryParams{Metric: metric, Duration: duration, MetricType: "Counter"}
+	params := queryParams{Metric: metric, Duration: duration, MetricType: metricType}
 	query, err := createQuery(params, metricLastLossTempl)
 	if err != nil {
 		return 0, err
@@ -563,81 +596,178 @@ func IngestionRate(v1api v1.API, metric string, duration, offset int) (float64,
 	return strconv.ParseFloat(values[0].Value, 64)
 }
 
-// Reference: https://www.robustperception.io/finding-churning-targets-in-prometheus-with-scrape_series_added
-func SystemChurnRate(v1api v1.API, offset int) (float64, error) {
+// systemStat returns the current value of a system stat along with its values over the duration
+func systemStat(v1api v1.API, templ string, duration, offset int) (float64, []model.SamplePair, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
 	defer cancel()
 
-	r, _, err := v1api.Query(ctx, systemChurnRateQuery, time.Now().Add(-time.Duration(offset)*time.Second))
+	params := queryParams{Duration: duration}
+	query, err := createQuery(params, templ)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
-	by, err := json.Marshal(r)
+	end := time.Now().Add(-time.Duration(offset) * time.Second)
+	r, _, err := v1api.Query(ctx, query, end)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
-	values, err := UnmarshalJSON(by)
+	current := 0.0
+	if v, ok := r.(model.Vector); ok && len(v) != 0 {
+		current = float64(v[0].Value)
+	}
+
+	step := time.Duration(duration) * time.Second / systemTrendPoints
+	if step < time.Second {
+		step = time.Second
+	}
+
+	rr, _, err := v1api.QueryRange(ctx, query, v1.Range{Start: end.Add(-time.Duration(duration) * time.Second), End: end, Step: step})
 	if err != nil {
-		return 0, err
+		return current, nil, err
 	}
 
-	if len(values) == 0 {
-		return 0, nil
+	if m, ok := rr.(model.Matrix); ok && len(m) != 0 {
+		return current, m[0].Values, nil
 	}
 
-	return strconv.ParseFloat(values[0].Value, 64)
+	return current, nil, nil
 }
 
-func SystemIngestionRate(v1api v1.API, offset int) (float64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
-	defer cancel()
-
-	r, _, err := v1api.Query(ctx, systemIngestionRateQuery, time.Now().Add(-time.Duration(offset)*time.Second))
-	if err != nil {
-		return 0, err
+// SystemChurnRate finds no. of new series created per second
+func SystemChurnRate(v1api v1.API, backend string, duration, offset int) (float64, []model.SamplePair, error) {
+	templ := vmSystemChurnRateTempl
+	if backend == BackendPrometheus {
+		templ = promSystemChurnRateTempl
 	}
 
-	by, err := json.Marshal(r)
-	if err != nil {
-		return 0, err
-	}
+	return systemStat(v1api, templ, duration, offset)
+}
 
-	values, err := UnmarshalJSON(by)
-	if err != nil {
-		return 0, err
+// SystemIngestionRate finds no. of samples ingested per second
+func SystemIngestionRate(v1api v1.API, backend string, duration, offset int) (float64, []model.SamplePair, error) {
+	templ := vmSystemIngestionRateTempl
+	if backend == BackendPrometheus {
+		templ = promSystemIngestionRateTempl
 	}
 
-	if len(values) == 0 {
-		return 0, nil
+	return systemStat(v1api, templ, duration, offset)
+}
+
+// SystemActiveTimeSeries finds no. of series which are currently active
+func SystemActiveTimeSeries(v1api v1.API, backend string, duration, offset int) (float64, []model.SamplePair, error) {
+	templ := vmSystemActiveTimeSeriesTempl
+	if backend == BackendPrometheus {
+		templ = promSystemActiveTimeSeriesTempl
 	}
 
-	return strconv.ParseFloat(values[0].Value, 64)
+	return systemStat(v1api, templ, duration, offset)
 }
 
-func SystemActiveTimeSeries(v1api v1.API, offset int) (uint64, error) {
+// SeriesCountByLabelValue counts series of the metric per value of label using the series api,
+// series without the label are not counted
+func SeriesCountByLabelValue(v1api v1.API, metric, label string, start, end time.Time) (map[string]uint64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
 	defer cancel()
 
-	r, _, err := v1api.Query(ctx, activeTimeSeriesQuery, time.Now().Add(-time.Duration(offset)*time.Second))
+	series, _, err := v1api.Series(ctx, []string{metric}, start, end)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	by, err := json.Marshal(r)
-	if err != nil {
-		return 0, err
+	counts := map[string]uint64{}
+	for i := range series {
+		v, ok := series[i][model.LabelName(label)]
+		if !ok {
+			continue
+		}
+		counts[string(v)]++
 	}
 
-	values, err := UnmarshalJSON(by)
+	return counts, nil
+}
+
+func Targets(v1api v1.API) (v1.TargetsResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	return v1api.Targets(ctx)
+}
+
+// QueryVector returns the instant vector of query along with labels of each series
+func QueryVector(v1api v1.API, query string, offset int) (model.Vector, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	r, _, err := v1api.Query(ctx, query, time.Now().Add(-time.Duration(offset)*time.Second))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if len(values) == 0 {
-		return 0, nil
+	v, ok := r.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("expected vector result for query %s, got %s", query, r.Type())
 	}
 
-	return strconv.ParseUint(values[0].Value, 10, 64)
+	return v, nil
+}
+
+func Rules(v1api v1.API) (v1.RulesResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	return v1api.Rules(ctx)
+}
+
+// MetricNamesStats returns query requests per metric name, available on VictoriaMetrics
+// when metric names stats tracking is enabled
+func MetricNamesStats(v1api v1.API, limit string) (v1.MetricNamesStatsResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	return v1api.MetricNamesStats(ctx, limit)
+}
+
+// LabelValues returns values of label across series matching any of the selectors
+func LabelValues(v1api v1.API, label string, matches []string, start, end time.Time) (model.LabelValues, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	values, _, err := v1api.LabelValues(ctx, label, matches, start, end)
+	return values, err
+}
+
+// LabelNames returns names of labels of series matching the selectors, all series if none
+func LabelNames(v1api v1.API, matches []string, start, end time.Time) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	names, _, err := v1api.LabelNames(ctx, matches, start, end)
+	return names, err
+}
+
+// Series returns label sets of series matching any of the selectors
+func Series(v1api v1.API, matches []string, start, end time.Time) ([]model.LabelSet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	series, _, err := v1api.Series(ctx, matches, start, end)
+	return series, err
+}
+
+// Metadata returns metadata of the metric from the metadata api
+func Metadata(v1api v1.API, metric string) (map[string][]v1.Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	return v1api.Metadata(ctx, metric, "")
+}
+
+// TargetsMetadata returns metadata of the metric as exposed by each target
+func TargetsMetadata(v1api v1.API, metric string) ([]v1.MetricMetadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	return v1api.TargetsMetadata(ctx, "", metric, "")
 }
@@ -383,6 +383,7 @@ const (
 	epTSDB            = apiPrefix + "/status/tsdb"
 	epTSDBWithMetric  = apiPrefix + "/status/tsdb"
 	epTopQueries      = apiPrefix + "/status/top_queries"
+	epMetricNamesStat = apiPrefix + "/status/metric_names_stats"
 	epWalReplay       = apiPrefix + "/status/walreplay"
 )
 
@@ -506,6 +507,8 @@ type API interface {
 	Metadata(ctx context.Context, metric, limit string) (map[string][]Metadata, error)
 	// Top Queries
 	TopQueries(ctx context.Context, topN, topNMaxLifeTime string) (TopQueriesResult, error)
+	// MetricNamesStats returns no. of query requests per metric name, VictoriaMetrics only
+	MetricNamesStats(ctx context.Context, limit string) (MetricNamesStatsResult, error)
 	// TSDB returns the cardinality statistics.
 	TSDB(ctx context.Context, topN, date string) (TSDBResult, error)
 	// TSDBWithMetric return stats specific to the metric
@@ -700,7 +703,22 @@ type TopQueriesResult struct {
 	TopBySumDuration     []map[string]interface{} `json:"topBySumDuration"`
 }
 
+// MetricNamesStatsResult contains the result from querying the metric names stats endpoint.
+type MetricNamesStatsResult struct {
+	Status              string             `json:"status"`
+	StatsCollectedSince int64              `json:"statsCollectedSince"`
+	Records             []MetricNameRecord `json:"records"`
+}
+
+// MetricNameRecord models query usage of a metric name.
+type MetricNameRecord struct {
+	MetricName           string `json:"metricName"`
+	QueryRequestsCount   uint64 `json:"queryRequestsCount"`
+	LastRequestTimestamp int64  `json:"lastRequestTimestamp"`
+}
+
 type TSDBWithMetricResult struct {
+	TotalSeries                  uint64 `json:"totalSeries,omitempty"`
 	SeriesCountByMetricName      []Stat `json:"seriesCountByMetricName"`
 	LabelValueCountByLabelName   []Stat `json:"labelValueCountByLabelName"`
 	SeriesCountByFocusLabelValue []Stat `json:"seriesCountByFocusLabelValue"`
@@ -1319,7 +1337,10 @@ func (h *httpAPI) TSDBWithMetric(ctx context.Context, metric, focusLabel, topN,
 		q.Set("date", date)
 	}
 
-	q.Set("match[]", metric)
+	// without match[] stats are for all series, useful along with focusLabel
+	if metric != "" {
+		q.Set("match[]", metric)
+	}
 
 	u.RawQuery = q.Encode()
 	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
@@ -1367,6 +1388,40 @@ func (h *httpAPI) TopQueries(ctx context.Context, topN, topNMaxLifeTime string)
 	return res, err
 }
 
+func (h *httpAPI) MetricNamesStats(ctx context.Context, limit string) (MetricNamesStatsResult, error) {
+	u := h.client.URL(epMetricNamesStat, nil)
+	q := u.Query()
+
+	q.Set("limit", limit)
+
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return MetricNamesStatsResult{}, err
+	}
+
+	req = req.WithContext(ctx)
+	client := http.DefaultClient
+	r, err := client.Do(req)
+	if err != nil {
+		return MetricNamesStatsResult{}, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return MetricNamesStatsResult{}, fmt.Errorf("metric names stats are not available, status code %d", r.StatusCode)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return MetricNamesStatsResult{}, err
+	}
+
+	var res MetricNamesStatsResult
+	err = json.Unmarshal(body, &res)
+	return res, err
+}
+
 func (h *httpAPI) TSDB(ctx context.Context, topN, date string) (TSDBResult, error) {
 	u := h.client.URL(epTSDB, nil)
 	q := u.Query()
@@ -20,7 +20,7 @@ var ccCmd = &cobra.Command{
 1. Cardinality of a metric.
 2. Find cardinality as per specific filter.
 3. Find unique counts of labels.
-4. Find cardinality contribution of each label or pair of labels.`,
+4. Find cardinality contribution of each label or combination of labels.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if cmd.Flags().Arg(0) == "" {
 			fmt.Println("Metric name cannot be empty for metric info mode")
@@ -28,6 +28,7 @@ var ccCmd = &cobra.Command{
 		}
 
 		c.Metric = cmd.Flags().Arg(0)
+		c.CostModel = config.CostModel
 
 		mode.CardinalityInvoke(config.DataSource, c)
 	},
@@ -36,7 +37,9 @@ var ccCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(ccCmd)
 	ccCmd.PersistentFlags().StringVar(&c.FilterLabel, "filter-label", "", "Use filter to consider to get limited timeseries")
-	ccCmd.PersistentFlags().IntVar(&c.LabelCount, "label-count", 1, "No. of labels to consider for cardinality, currently supports 1 and 2")
+	ccCmd.PersistentFlags().IntVar(&c.LabelCount, "label-count", 1, "No. of labels to consider together for cardinality contribution, combinations are evaluated from 1 up to this no. and only those of this no. are reported")
+	ccCmd.PersistentFlags().IntVar(&c.QueryBudget, "query-budget", 500,
+		"Max no. of queries to spend on combinations of 2 or more labels, combinations beyond this are not evaluated")
 	ccCmd.PersistentFlags().IntVar(&c.CardinalityPerDuration, "cc-duration", 43200,
 		"Cardinality duration for labels contribution. [Note]: this is not for unique label count of each label")
 	ccCmd.PersistentFlags().IntVar(&c.Lag, "lag", 60, "Lag to consider from current time to calculate cardinality")
@@ -46,5 +49,9 @@ func init() {
 		"Specify the acceptable limit, if the cardinality execeeds beyond this then relative cardinality kicks in.")
 	ccCmd.PersistentFlags().StringVar(&c.DumpAs, "dump-as", "csv", "Dump format, allowed values csv, table")
 	ccCmd.PersistentFlags().StringArrayVar(&c.Label, "labels", []string{}, "Labels to consider for cardinality")
+	ccCmd.PersistentFlags().BoolVar(&c.Entropy, "entropy", false,
+		"Add entropy, gini and pareto statistics of each label and rank labels by drop score")
+	ccCmd.PersistentFlags().BoolVar(&c.Cost, "cost", false,
+		"Estimate series, storage and money saved by dropping each label using cost_model from config")
 	ccCmd.PersistentFlags().BoolVar(&c.DisableRelativeCardinality, "disable-relative-cardinality", false, "Disable the implicit behaviour of applying relative cardinality")
 }
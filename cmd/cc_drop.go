@@ -15,7 +15,12 @@ var ccDropCmd = &cobra.Command{
 	Short: "To provide analysis if drop action is selected",
 	Long: `Provides capability to find:
 
-1. If any label or combination is dropped, is it going to result into duplicates.`,
+1. If any label or combination is dropped, is it going to result into duplicates.
+2. Alerting and recording rules which break if it is dropped, as the rule uses the label in
+   matchers, by, without, on or ignoring. Rules are fetched from the rules API, or read from --rule-files.
+3. Grafana dashboards which depend on it, with --dashboards-dir.
+4. Series, storage and money saved by dropping it, with --cost.
+5. Top queries which reference the metric, whether they use the label and expected speed up, with --top-queries.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// TODO: fix this positional arg passing across sub-commands
 		if cmd.Flags().Arg(0) == "" {
@@ -24,6 +29,7 @@ var ccDropCmd = &cobra.Command{
 		}
 
 		c.Metric = cmd.Flags().Arg(0)
+		c.CostModel = config.CostModel
 
 		c.DropAction = true
 		mode.CardinalityInvoke(config.DataSource, c)
@@ -32,4 +38,10 @@ var ccDropCmd = &cobra.Command{
 
 func init() {
 	ccCmd.AddCommand(ccDropCmd)
+	ccDropCmd.PersistentFlags().StringArrayVar(&c.RuleFiles, "rule-files", []string{},
+		"Rule files(glob patterns allowed) to check drops against, instead of rules API")
+	ccDropCmd.PersistentFlags().StringVar(&c.DashboardsDir, "dashboards-dir", "", "Directory of grafana dashboard JSON files to check drops against")
+	ccDropCmd.PersistentFlags().BoolVar(&c.TopQueries, "top-queries", false, "Check drops against top queries")
+	ccDropCmd.PersistentFlags().StringVar(&c.QueriesTopN, "queries-topN", "100", "No. of top queries to consider from each list")
+	ccDropCmd.PersistentFlags().StringVar(&c.TopNMaxLifeTime, "top-query-max-lifetime", "3600", "Duration to check for top queries(in seconds)")
 }
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pree-dew/metric-explorer/mode"
+
+	"github.com/spf13/cobra"
+)
+
+// ccOptimizeCmd finds the minimal set of labels to drop to reach a cardinality target
+var ccOptimizeCmd = &cobra.Command{
+	Use:   "optimize [metric]",
+	Short: "To find minimal label drops which bring cardinality under a target",
+	Long: `Provides capability to find:
+
+1. Minimal sets of labels which when dropped bring the cardinality under the target.
+2. Resulting series count of each drop plan.
+3. If a drop plan is going to result into duplicates.
+
+Label subsets are searched greedily, small label sets are also searched exhaustively.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// TODO: fix this positional arg passing across sub-commands
+		if cmd.Flags().Arg(0) == "" {
+			fmt.Println("Metric name cannot be empty for metric info mode")
+			os.Exit(1)
+		}
+
+		if c.Target <= 0 {
+			fmt.Println("Please provide a target cardinality using --target")
+			os.Exit(1)
+		}
+
+		c.Metric = cmd.Flags().Arg(0)
+
+		mode.OptimizeInvoke(config.DataSource, c)
+	},
+}
+
+func init() {
+	ccCmd.AddCommand(ccOptimizeCmd)
+	ccOptimizeCmd.PersistentFlags().Int64Var(&c.Target, "target", 0, "Cardinality to reach after dropping labels")
+	ccOptimizeCmd.PersistentFlags().IntVar(&c.ExhaustiveLimit, "exhaustive-limit", 6,
+		"Search all label subsets if no. of labels is within this limit, otherwise only greedy search is used")
+	ccOptimizeCmd.PersistentFlags().IntVar(&c.MaxPlans, "max-plans", 10, "No. of drop plans to present, arranged in increasing order of labels dropped")
+	ccOptimizeCmd.PersistentFlags().BoolVar(&c.AllowDuplicates, "allow-duplicates", true,
+		"Also present drop plans which result into duplicates, they need aggregation before labels are dropped")
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pree-dew/metric-explorer/mode"
+
+	"github.com/spf13/cobra"
+)
+
+// ccRelationsCmd classifies the relationship between every pair of labels of a metric
+var ccRelationsCmd = &cobra.Command{
+	Use:   "relations [metric]",
+	Short: "To classify relationship between labels of a metric",
+	Long: `Provides capability to find:
+
+1. Unique values of each label and each pair of labels.
+2. Relationship of every label pair as 1:1, 1:N, N:1 or M:N.
+3. Redundant labels which can be dropped without any change in cardinality.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// TODO: fix this positional arg passing across sub-commands
+		if cmd.Flags().Arg(0) == "" {
+			fmt.Println("Metric name cannot be empty for metric info mode")
+			os.Exit(1)
+		}
+
+		c.Metric = cmd.Flags().Arg(0)
+
+		mode.RelationsInvoke(config.DataSource, c)
+	},
+}
+
+func init() {
+	ccCmd.AddCommand(ccRelationsCmd)
+}
@@ -26,7 +26,12 @@ var minfoCmd = &cobra.Command{
 - Ingestion rate in past x seconds.
 - Sparse Percentage of a metric. Average duration for which metric is absent.
 - Active timeseries in past x seconds
-- If counter, last reset times.`,
+- If counter, last reset times. Type of the metric is detected from metadata,
+  naming conventions and sample behaviour.
+- Distribution of series across values of a label.
+- Series count trend over days and forecast of crossing limit.
+- For histograms, distribution of observations across buckets and a reduced bucket set.
+- For histograms, buckets exposed by each instance and bucket counts which aren't cumulative.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if cmd.Flags().Arg(0) == "" {
 			fmt.Println("Metric name cannot be empty for metric info mode")
@@ -80,6 +85,16 @@ var minfoCmd = &cobra.Command{
 			m.ActiveTimeSeries = 0
 		}
 
+		isSet = cmd.PersistentFlags().Lookup("histogram").Changed
+		if !isSet {
+			m.Histogram = 0
+		}
+
+		isSet = cmd.PersistentFlags().Lookup("histogram-layout").Changed
+		if !isSet {
+			m.HistogramLayout = 0
+		}
+
 		cardinalityFlag := cmd.PersistentFlags().Lookup("cardinality")
 		isSet = cardinalityFlag.Changed
 		if !isSet {
@@ -90,6 +105,7 @@ var minfoCmd = &cobra.Command{
 			m.Cardinality = time.Now().UTC().Format("2006-01-02")
 		}
 
+		m.Limits = config.Limits
 		mode.MInfoInvoke(config.DataSource, m)
 	},
 }
@@ -108,8 +124,15 @@ func init() {
 	minfoCmd.PersistentFlags().IntVar(&m.SparseDuration, "sparse", 3600, "Check sparness for duration over x seconds")
 	minfoCmd.PersistentFlags().IntVar(&m.ResetTime, "reset-counts", 3600, "No. of times counter reset in x seconds")
 	minfoCmd.PersistentFlags().IntVar(&m.ActiveTimeSeries, "active-timeseries", 3600, "No. of active timeseries in duration of x seconds")
+	minfoCmd.PersistentFlags().IntVar(&m.Histogram, "histogram", 86400, "Distribution of observations across buckets of a histogram over past x seconds")
+	minfoCmd.PersistentFlags().IntVar(&m.HistogramLayout, "histogram-layout", 3600, "Compare buckets exposed by instances of a histogram, along with buckets dropped over past x seconds")
+	minfoCmd.PersistentFlags().StringVar(&m.VersionLabel, "version-label", "version", "Label holding version of the instance, to group bucket layouts by")
+	minfoCmd.PersistentFlags().IntVar(&m.Days, "days", 0, "No. of days for which series count trend should be presented along with forecast of crossing limit")
 	minfoCmd.PersistentFlags().IntVar(&m.Lag, "lag", 60, "Lag to consider for collecting stats")
-	minfoCmd.PersistentFlags().StringVar(&m.DumpAs, "dump-as", "csv", "Dump format, allowed values csv, table")
+	minfoCmd.PersistentFlags().StringVar(&m.DumpAs, "dump-as", "csv", "Dump format, allowed values csv, table, json (json is supported by distribution only)")
+	minfoCmd.PersistentFlags().BoolVar(&m.Entropy, "entropy", false, "Add entropy, gini and pareto statistics of each label along with cardinality information")
+	minfoCmd.PersistentFlags().StringVar(&m.Distribution, "distribution", "", "Label for which cumulative share of series across its values should be presented")
+	minfoCmd.PersistentFlags().StringVar(&m.DistributionTopN, "distribution-topN", "100000", "No. of top label values to fetch for distribution")
 	minfoCmd.PersistentFlags().StringVar(&m.LabelCount, "label-count", "5", "No. of label values to present for each label along with cardinality information, arranged in decreassing order")
 
 	minfoCmd.PersistentFlags().Lookup("response-time").NoOptDefVal = "300"
@@ -120,5 +143,7 @@ func init() {
 	minfoCmd.PersistentFlags().Lookup("sparse").NoOptDefVal = "3600"
 	minfoCmd.PersistentFlags().Lookup("reset-counts").NoOptDefVal = "3600"
 	minfoCmd.PersistentFlags().Lookup("active-timeseries").NoOptDefVal = "3600"
+	minfoCmd.PersistentFlags().Lookup("histogram").NoOptDefVal = "86400"
+	minfoCmd.PersistentFlags().Lookup("histogram-layout").NoOptDefVal = "3600"
 	minfoCmd.PersistentFlags().Lookup("cardinality").NoOptDefVal = "today"
 }
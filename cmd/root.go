@@ -9,10 +9,18 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+	"github.com/pree-dew/metric-explorer/mode"
 )
 
 type Config struct {
-	DataSource string `yaml:"datasource"`
+	DataSource string           `yaml:"datasource"`
+	Backend    string           `yaml:"backend"`
+	Limits     mode.Limits      `yaml:"limits"`
+	Groups     []mode.GroupRule `yaml:"groups"`
+	Owners     []mode.OwnerRule `yaml:"owners"`
+	CostModel  mode.CostModel   `yaml:"cost_model" mapstructure:"cost_model"`
 }
 
 var (
@@ -28,10 +36,12 @@ var rootCmd = &cobra.Command{
 	Long: `A tool that helps in answering: I have detected high cardinality, what to do next?.
 	
 Provides capability to take decisions on how to control cardinality.
-Supports three modes:
+Supports five modes:
 1. System(system): To get system wide information about cardinality.
 2. Explore (explore): To know more details about specific metric.
-3. Cardinality Control(cc): To make decision to control cardinality`,
+3. Cardinality Control(cc): To make decision to control cardinality.
+4. Usage(usage): To find where metrics and labels are used.
+5. Rules(rules): To find broken alerting and recording rules.`,
 	// Run: func(cmd *cobra.Command, args []string) {},
 }
 
@@ -67,6 +77,14 @@ func initConfig() {
 		os.Exit(1)
 	}
 
+	viper.SetDefault("backend", apiclient.BackendVictoriaMetrics)
+	viper.SetDefault("cost_model.bytes_per_series", 4096)
+	viper.SetDefault("cost_model.bytes_per_sample", 1.3)
+	viper.SetDefault("cost_model.memory_price_per_gb_month", 3)
+	viper.SetDefault("cost_model.disk_price_per_gb_month", 0.1)
+	viper.SetDefault("cost_model.retention_days", 30)
+	viper.SetDefault("cost_model.scrape_interval", 30)
+
 	if err = viper.Unmarshal(&config); err != nil {
 		fmt.Println("Error while unmarshalling config file:", err)
 		os.Exit(1)
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+var rFlag mode.RulesFlag
+
+// rulesCmd represents the rules command
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Health of your alerting and recording rules",
+	Long: `Provides capability to find alerting and recording rules which are:
+
+1. Broken by missing metrics or labels(check).`,
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.PersistentFlags().StringVar(&rFlag.DumpAs, "dump-as", "csv", "Dump format, allowed values csv, table")
+}
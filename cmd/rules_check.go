@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// rulesCheckCmd finds rules selecting metrics or labels which no longer exist
+var rulesCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Rules referencing metrics or labels which no longer exist",
+	Long: `Parses expression of every alerting and recording rule, from the rules API or
+rule files passed with --rule-files, and verifies that:
+
+- Each selector matches atleast one series within --lookback.
+- Each label an aggregation groups by exists on the series it aggregates, labels
+  created by label_replace, label_join and count_values are left out.
+
+Rules failing either check are reported along with their dead selectors and
+missing grouping labels. Such rules evaluate to nothing, so alerts stay silently green.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		mode.RulesCheckInvoke(config.DataSource, rFlag)
+	},
+}
+
+func init() {
+	rulesCmd.AddCommand(rulesCheckCmd)
+	rulesCheckCmd.PersistentFlags().StringArrayVar(&rFlag.RuleFiles, "rule-files", []string{},
+		"Rule files(glob patterns allowed) to check, instead of rules API")
+	rulesCheckCmd.PersistentFlags().IntVar(&rFlag.Lookback, "lookback", 3600, "Window in which selectors must match series(in seconds)")
+}
@@ -23,21 +23,51 @@ var systemCmd = &cobra.Command{
 	Short: "Overview of your TSDB coverage",
 	Long: `Provides system wise information and has options to get overview of:
 
-- Metrics with high ingestion rates (Under Development).
+- System wide ingestion rate along with its trend.
 - Metrics with high cardinality.
-- Metrics with high churn rates (Under Development).
-- Active Timeseries (Under Development).`,
+- Most widespread label names, heaviest label value pairs, memory per label name and head stats.
+- Series count trend over days and forecast of crossing configured limits.
+- Series, samples per scrape and series added per scrape by job (or any label) and by target.
+- Heavy hitter label values across all metrics, e.g. a noisy namespace or pod.
+- Cardinality by metric name prefix or custom groups.
+- Series owned by each team for chargeback (system chargeback).
+- High cardinality metrics which are never queried (system unused).
+- System wide churn rate along with its trend.
+- Active Timeseries along with its trend.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if cmd.Flags().NFlag() < 2 {
 			fmt.Println("Please provide atleast 1 flag, refer --help for flag information")
 			os.Exit(1)
 		}
 
+		if sFlag.DrillDown < 0 {
+			fmt.Println("--drill-down cannot be negative")
+			os.Exit(1)
+		}
+
 		isSet := cmd.PersistentFlags().Lookup("cardinality").Changed
 		if !isSet {
 			sFlag.Cardinality = ""
 		}
 
+		isSet = cmd.PersistentFlags().Lookup("churn-rate").Changed
+		if !isSet {
+			sFlag.ChurnRate = 0
+		}
+
+		isSet = cmd.PersistentFlags().Lookup("ingestion-rate").Changed
+		if !isSet {
+			sFlag.IngestionRate = 0
+		}
+
+		isSet = cmd.PersistentFlags().Lookup("active-timeseries").Changed
+		if !isSet {
+			sFlag.ActiveTimeSeries = 0
+		}
+
+		sFlag.Limits = config.Limits
+		sFlag.Backend = config.Backend
+		sFlag.GroupRules = config.Groups
 		mode.SystemInvoke(config.DataSource, sFlag)
 	},
 }
@@ -47,11 +77,33 @@ func init() {
 	systemCmd.PersistentFlags().BoolVar(&sFlag.TopQueries, "top-queries", false, "Stats of top queries")
 	systemCmd.PersistentFlags().StringVar(&sFlag.Cardinality, "cardinality", "",
 		"Provide the date for which cardinality should be calculated, format is YYYY-MM-DD")
+	systemCmd.PersistentFlags().BoolVar(&sFlag.Labels, "labels", false, "Label names carried by most series")
+	systemCmd.PersistentFlags().BoolVar(&sFlag.LabelPairs, "label-pairs", false, "Label value pairs with most series")
+	systemCmd.PersistentFlags().BoolVar(&sFlag.Memory, "memory", false, "Memory used by each label name")
+	systemCmd.PersistentFlags().BoolVar(&sFlag.Head, "head", false, "Stats of TSDB head")
+	systemCmd.PersistentFlags().StringVar(&sFlag.By, "by", "",
+		"Label(e.g. job) whose values and scrape targets should be ranked by series, samples per scrape and series added per scrape")
+	systemCmd.PersistentFlags().Float64Var(&sFlag.OutlierFactor, "outlier-factor", 10,
+		"Flag targets whose size is this many times away from median size of targets of the same job")
+	systemCmd.PersistentFlags().StringVar(&sFlag.FocusLabel, "focus-label", "",
+		"Label(e.g. namespace, pod) whose values should be ranked by series across all metrics")
+	systemCmd.PersistentFlags().IntVar(&sFlag.DrillDown, "drill-down", 5, "No. of top focus label values to drill into for contributing metrics")
+	systemCmd.PersistentFlags().BoolVar(&sFlag.GroupByPrefix, "group-by-prefix", false,
+		"Aggregate cardinality of all metrics by name prefix and custom groups from config")
+	systemCmd.PersistentFlags().StringVar(&sFlag.PrefixSeparators, "prefix-separators", "_:", "Characters which separate parts of a metric name")
+	systemCmd.PersistentFlags().IntVar(&sFlag.PrefixDepth, "prefix-depth", 1, "No. of name parts to consider as prefix, e.g. 2 for kube_pod_")
+	systemCmd.PersistentFlags().StringVar(&sFlag.Group, "group", "", "Group to drill down into for its metrics")
 	systemCmd.PersistentFlags().StringVar(&sFlag.TopN, "topN", "20", "Details of top N metrics")
 	systemCmd.PersistentFlags().StringVar(&sFlag.TopNMaxLifeTime, "top-query-max-lifetime", "3600", "Duration to check for top queries(in seconds)")
+	systemCmd.PersistentFlags().IntVar(&sFlag.Days, "days", 0,
+		"No. of days for which series count trend of top N metrics should be presented along with forecast of crossing limits")
 	systemCmd.PersistentFlags().StringVar(&sFlag.DumpAs, "dump-as", "csv", "Dump format, allowed values csv, table")
+	systemCmd.PersistentFlags().IntVar(&sFlag.ChurnRate, "churn-rate", 3600, "System wide churn rate over past x seconds")
+	systemCmd.PersistentFlags().IntVar(&sFlag.IngestionRate, "ingestion-rate", 3600, "System wide ingestion rate over past x seconds")
+	systemCmd.PersistentFlags().IntVar(&sFlag.ActiveTimeSeries, "active-timeseries", 3600, "System wide active timeseries over past x seconds")
+	systemCmd.PersistentFlags().IntVar(&sFlag.Lag, "lag", 60, "Lag to consider for collecting stats")
 	systemCmd.PersistentFlags().Lookup("cardinality").NoOptDefVal = "today"
-
-	// Churn Rate, Ingestion Rate and Active Timeseries are currently under development
-	// for system mode
+	systemCmd.PersistentFlags().Lookup("churn-rate").NoOptDefVal = defaultSystemChurnRateDuration
+	systemCmd.PersistentFlags().Lookup("ingestion-rate").NoOptDefVal = defaultSystemIngestionRateDuration
+	systemCmd.PersistentFlags().Lookup("active-timeseries").NoOptDefVal = defaultSystemActiveTimeseriesDuration
 }
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// systemChargebackCmd attributes series to teams configured as owners
+var systemChargebackCmd = &cobra.Command{
+	Use:   "chargeback",
+	Short: "Bill cardinality back to teams",
+	Long: `Attributes series of top N metrics to teams using owners section of config and provides:
+
+- Series owned by each team and its share of total series.
+- Day over day growth of series owned by each team.
+- Series not owned by any team (unowned) and series of metrics outside top N.
+
+A metric whose name matches metric regex of a team is owned by it as a whole, otherwise
+its series are split using series count per value of job and matcher labels.
+
+[Note]: Rules are applied in order of config, a series matching rules of more than one team is owned by the first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sFlag.Owners = config.Owners
+		mode.SystemChargebackInvoke(config.DataSource, sFlag)
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemChargebackCmd)
+	systemChargebackCmd.PersistentFlags().StringVar(&sFlag.Date, "date", "", "Date for which series should be attributed, format is YYYY-MM-DD (default yesterday)")
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// systemDiffCmd compares TSDB stats of two dates
+var systemDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "What changed in your TSDB between two dates",
+	Long: `Compares TSDB stats of two dates and provides:
+
+- Newly appearing metrics.
+- Disappeared metrics.
+- Metrics whose series count grew beyond the allowed growth.
+- Same for label value pairs, to find a newly exploding label value.
+
+[Note]: Stats are limited to top N entries, so new or disappeared may also mean moved in or out of top N.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if sFlag.From == "" || sFlag.To == "" {
+			fmt.Println("Please provide both --from and --to dates, format is YYYY-MM-DD")
+			os.Exit(1)
+		}
+
+		mode.SystemDiffInvoke(config.DataSource, sFlag)
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemDiffCmd)
+	systemDiffCmd.PersistentFlags().StringVar(&sFlag.From, "from", "", "Date to compare from, format is YYYY-MM-DD")
+	systemDiffCmd.PersistentFlags().StringVar(&sFlag.To, "to", "", "Date to compare to, format is YYYY-MM-DD")
+	systemDiffCmd.PersistentFlags().Float64Var(&sFlag.GrowthPercent, "growth-percent", 20, "Report series count growth beyond this percentage")
+	systemDiffCmd.PersistentFlags().Uint64Var(&sFlag.GrowthAbsolute, "growth-absolute", 1000, "Report series count growth beyond this absolute no.")
+}
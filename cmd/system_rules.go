@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// systemRulesCmd reports evaluation cost of alerting and recording rules
+var systemRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Evaluation cost of alerting and recording rules",
+	Long: `Shows for each rule of each group:
+
+- Evaluation time, last evaluation time and health.
+- Series read by the rule expression.
+- Series recorded by recording rules.
+
+Rules are ranked by evaluation time and series recorded. Recording rules whose
+recorded series are atleast --useless-ratio of the series they read are flagged,
+as the aggregation hardly reduces series.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		mode.SystemRulesInvoke(config.DataSource, sFlag)
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemRulesCmd)
+	systemRulesCmd.PersistentFlags().Float64Var(&sFlag.UselessRatio, "useless-ratio", 0.9, "Output to input series ratio above which a recording rule is flagged")
+}
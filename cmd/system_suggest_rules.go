@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// systemSuggestRulesCmd suggests recording rules for aggregations run repeatedly
+var systemSuggestRulesCmd = &cobra.Command{
+	Use:   "suggest-rules",
+	Short: "Recording rules for expensive repeated queries",
+	Long: `Clusters top queries which only differ in time ranges and matcher values,
+e.g. the same panel viewed with different dashboard variables, and suggests a
+recording rule named as level:metric:operations for each aggregation run at
+least --min-count times.
+
+Matchers whose value varies within a cluster are dropped from the rule and their
+labels are kept in the output, so that every query of the cluster can be answered
+from the recorded series.
+
+Shows series read by each rule, new series it would record and estimated query time
+saved, followed by the rules in rule file format.
+
+[Note]: Time saved assumes query time is proportional to series read, rule evaluation cost isn't included.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		mode.SystemSuggestRulesInvoke(config.DataSource, sFlag)
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemSuggestRulesCmd)
+	systemSuggestRulesCmd.PersistentFlags().StringVar(&sFlag.QueriesTopN, "queries-topN", "1000", "No. of top queries to consider from each list")
+	systemSuggestRulesCmd.PersistentFlags().IntVar(&sFlag.MinQueryCount, "min-count", 10, "Min no. of times queries of a cluster ran to suggest a rule")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// systemUnusedCmd finds high cardinality metrics which nobody queries
+var systemUnusedCmd = &cobra.Command{
+	Use:   "unused",
+	Short: "High cardinality metrics which are never queried",
+	Long: `Collects metric names referenced by:
+
+- Top queries, all three lists (by count, by average duration, by sum of duration).
+- Expressions of alerting and recording rules.
+- Grafana dashboard JSON files under --dashboards-dir.
+- Metric names stats on VictoriaMetrics, where tracking is enabled.
+
+and lists top N metrics which are never referenced along with their series count.
+
+[Note]: Top queries only cover queries run within --top-query-max-lifetime, consider raising it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sFlag.Backend = config.Backend
+		mode.SystemUnusedInvoke(config.DataSource, sFlag)
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemUnusedCmd)
+	systemUnusedCmd.PersistentFlags().StringVar(&sFlag.DashboardsDir, "dashboards-dir", "", "Directory of grafana dashboard JSON files")
+	systemUnusedCmd.PersistentFlags().StringVar(&sFlag.QueriesTopN, "queries-topN", "1000", "No. of top queries to consider from each list")
+}
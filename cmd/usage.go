@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+var uFlag mode.UsageFlag
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Where your metrics and labels are used",
+	Long: `Provides capability to find which metrics and labels are used by:
+
+1. Grafana dashboards(dashboards).`,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+	usageCmd.PersistentFlags().StringVar(&uFlag.DumpAs, "dump-as", "csv", "Dump format, allowed values csv, table")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pree-dew/metric-explorer/mode"
+)
+
+// usageDashboardsCmd builds metric to labels index of grafana dashboards
+var usageDashboardsCmd = &cobra.Command{
+	Use:   "dashboards",
+	Short: "Metrics and labels used by grafana dashboards",
+	Long: `Parses grafana dashboard JSON files, queries of panels, panels nested in rows and
+template variables, and provides every metric along with labels the dashboards depend on.
+
+Variables like $job, ${job}, ${job:regex} and [[job]] are resolved to wildcards.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if uFlag.Dir == "" {
+			fmt.Println("Please provide directory of dashboards using --dir")
+			os.Exit(1)
+		}
+
+		mode.DashboardUsageInvoke(uFlag)
+	},
+}
+
+func init() {
+	usageCmd.AddCommand(usageDashboardsCmd)
+	usageDashboardsCmd.PersistentFlags().StringVar(&uFlag.Dir, "dir", "", "Directory of grafana dashboard JSON files")
+}
@@ -1,12 +1,17 @@
 package mode
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
 )
 
 var (
@@ -28,18 +33,23 @@ type labelInfo struct {
 	cardinalityPer  int
 	values          []string
 	duplicateExists bool
+	// rules which break if the label is dropped
+	blockedBy []string
+	// dashboards which depend on the label
+	dashboards []string
 }
 
 type labelMap map[string]labelInfo
 
-func dumpTopQueriesView(topQueries []map[string]interface{}, format string) {
+func dumpTopQueriesView(title string, topQueries []map[string]interface{}, format string) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"top queries"})
-	t.AppendHeader(table.Row{"Query", "Time Range(in seconds)", "Average Response Time(in seconds)", "Count"})
+	t.AppendHeader(table.Row{title})
+	t.AppendHeader(table.Row{"Query", "Time Range(in seconds)", "Average Response Time(in seconds)", "Count", "Sum Response Time(in seconds)"})
 	t.AppendSeparator()
 	for i := range topQueries {
-		in := make([]interface{}, 4)
+		// each list carries only some of the stats
+		in := table.Row{"-", "-", "-", "-", "-"}
 		for k, v := range topQueries[i] {
 			switch k {
 			case "query":
@@ -50,6 +60,8 @@ func dumpTopQueriesView(topQueries []map[string]interface{}, format string) {
 				in[2] = v
 			case "count":
 				in[3] = v
+			case "sumDurationSeconds":
+				in[4] = v
 			}
 		}
 		t.AppendRow(in)
@@ -66,12 +78,64 @@ func dumpTopQueriesView(topQueries []map[string]interface{}, format string) {
 	fmt.Println()
 }
 
-func dumpCardinalityInfoWithLabels(metric string, cardinality uint64, labels labelMap, labelValues map[string][]map[string]uint64, format string) {
+func dumpSelectorCostView(costs []*selectorCost, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Selectors of top queries"})
+	t.AppendHeader(table.Row{"Query", "Selector", "Series", "Lookback", "Time Range(in seconds)", "Sum Response Time(in seconds)"})
+	var prev *topQuery
+	for _, c := range costs {
+		if prev != nil && prev != c.query {
+			t.AppendSeparator()
+		}
+		query := ""
+		if prev != c.query {
+			query = c.query.query
+		}
+		prev = c.query
+		t.AppendRow(table.Row{query, c.selector, c.series, c.lookback.String(), c.query.timeRange, c.query.sumDuration})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpMetricQueryCostView(metrics []*metricQueryCost, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Query time spent per metric"})
+	t.AppendHeader(table.Row{"Metric", "Queries", "Series", "Query Time(in seconds)", "Query Time %"})
+	for _, m := range metrics {
+		t.AppendRow(table.Row{m.metric, m.queries, m.series, m.queryTime, m.percentage})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpCardinalityInfoWithLabels(metric, metricType string, cardinality uint64, labels labelMap, labelValues map[string][]map[string]uint64, stats map[string]distributionStats, format string) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"Metric", metric})
+	if metricType != "" {
+		t.AppendHeader(table.Row{"Type", metricType})
+	}
 	t.AppendHeader(table.Row{"Cardinality", cardinality})
-	t.AppendHeader(table.Row{"Label", "Unique Value", "Label Values"})
+	if stats == nil {
+		t.AppendHeader(table.Row{"Label", "Unique Value", "Label Values"})
+	} else {
+		t.AppendHeader(append(table.Row{"Label", "Unique Value", "Label Values"}, statsHeaders()...))
+	}
 	t.AppendSeparator()
 	for k, v := range labels {
 		lString := []string{}
@@ -81,7 +145,11 @@ func dumpCardinalityInfoWithLabels(metric string, cardinality uint64, labels lab
 			}
 		}
 
-		t.AppendRow([]interface{}{k, v.uniqueCount, strings.Join(lString, "\n")})
+		row := table.Row{k, v.uniqueCount, strings.Join(lString, "\n")}
+		if stats != nil {
+			row = append(row, statsColumns(stats[k], float64(v.cardinalityPer))...)
+		}
+		t.AppendRow(row)
 	}
 
 	t.AppendSeparator()
@@ -94,6 +162,14 @@ func dumpCardinalityInfoWithLabels(metric string, cardinality uint64, labels lab
 	fmt.Println()
 }
 
+func statsHeaders() table.Row {
+	return table.Row{"Entropy (bits)", "Normalised Entropy", "Gini", "Top 20% Values Share %", "Drop Score"}
+}
+
+func statsColumns(st distributionStats, cost float64) table.Row {
+	return table.Row{round2(st.entropy), round2(st.normEntropy), round2(st.gini), round2(st.topShare), round2(dropScore(cost, st))}
+}
+
 func sortLabelMap(labels labelMap) []stringIntMap {
 	lc := make([]stringIntMap, len(labels))
 
@@ -133,7 +209,7 @@ func getHeaders(noOfLabels int, action string) table.Row {
 		}
 
 		if action == drop {
-			return table.Row{"Label", "Unique Value", "Cardinality %", "Duplicate Labels Exists"}
+			return table.Row{"Label", "Unique Value", "Cardinality %", "Duplicate Labels Exists", "Blocked By Rules", "Used By Dashboards"}
 		}
 	}
 
@@ -141,23 +217,71 @@ func getHeaders(noOfLabels int, action string) table.Row {
 		return table.Row{"Label", "Cardinality %"}
 	}
 
-	return table.Row{"Label", "Cardinality %", "Duplicate Labels Exists"}
+	return table.Row{"Label", "Cardinality %", "Duplicate Labels Exists", "Blocked By Rules", "Used By Dashboards"}
+}
+
+func blockedVerdict(l labelInfo) string {
+	if len(l.blockedBy) == 0 {
+		return "-"
+	}
+
+	return "blocked by rule " + strings.Join(l.blockedBy, ", ")
+}
+
+func dashboardsVerdict(l labelInfo) string {
+	if len(l.dashboards) == 0 {
+		return "-"
+	}
+
+	return strings.Join(l.dashboards, ", ")
 }
 
-func dumpCardinalityInfoPerLabel(metric string, cardinality uint64, labelInfo labelsCardinalityInfo, action, format string) {
+func costHeaders() table.Row {
+	return table.Row{"Saves Series", "Saves GB", "Saves $/Month"}
+}
+
+func costColumns(f footprint) table.Row {
+	return table.Row{f.series, formatCost(f.memoryGB + f.diskGB), formatCost(f.monthly)}
+}
+
+func dumpCardinalityInfoPerLabel(metric string, cardinality uint64, labelInfo labelsCardinalityInfo, stats map[string]distributionStats, costs map[string]footprint, action, format string) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"Metric", metric})
 	t.AppendHeader(table.Row{"Cardinality", cardinality})
-	t.AppendHeader(getHeaders(1, action))
+	header := getHeaders(1, action)
+	if stats != nil {
+		header = append(header, statsHeaders()...)
+	}
+	if costs != nil {
+		header = append(header, costHeaders()...)
+	}
+	t.AppendHeader(header)
 	t.AppendSeparator()
 	lc := sortMap(labelInfo)
+
+	// rank drop candidates by drop score when information value is known
+	if stats != nil {
+		sort.SliceStable(lc, func(i, j int) bool {
+			return dropScore(float64(labelInfo[lc[i].key].cardinalityPer), stats[lc[i].key]) >
+				dropScore(float64(labelInfo[lc[j].key].cardinalityPer), stats[lc[j].key])
+		})
+	}
+
 	for _, v := range lc {
-		if action == "" {
-			t.AppendRow([]interface{}{v.key, v.value, labelInfo[v.key].cardinalityPer})
-		} else {
-			t.AppendRow([]interface{}{v.key, v.value, labelInfo[v.key].cardinalityPer, labelInfo[v.key].duplicateExists})
+		row := table.Row{v.key, v.value, labelInfo[v.key].cardinalityPer}
+		if action != "" {
+			row = append(row, labelInfo[v.key].duplicateExists, blockedVerdict(labelInfo[v.key]), dashboardsVerdict(labelInfo[v.key]))
+		}
+
+		if stats != nil {
+			row = append(row, statsColumns(stats[v.key], float64(labelInfo[v.key].cardinalityPer))...)
 		}
+
+		if costs != nil {
+			row = append(row, costColumns(costs[v.key])...)
+		}
+		t.AppendRow(row)
 	}
 
 	t.AppendSeparator()
@@ -170,16 +294,24 @@ func dumpCardinalityInfoPerLabel(metric string, cardinality uint64, labelInfo la
 	fmt.Println()
 }
 
-func dumpCardinalityInfoWithoutLabels(metric string, cardinality uint64, labels labelMap, format string) {
+func dumpCardinalityInfoWithoutLabels(metric string, cardinality uint64, labels labelMap, singles labelsCardinalityInfo, stats map[string]distributionStats, format string) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"Metric", metric})
 	t.AppendHeader(table.Row{"Cardinality", cardinality})
-	t.AppendHeader(table.Row{"Label", "Unique Value"})
+	if stats == nil {
+		t.AppendHeader(table.Row{"Label", "Unique Value"})
+	} else {
+		t.AppendHeader(append(table.Row{"Label", "Unique Value"}, statsHeaders()...))
+	}
 	t.AppendSeparator()
 	lc := sortLabelMap(labels)
 	for _, v := range lc {
-		t.AppendRow([]interface{}{v.key, v.value})
+		row := table.Row{v.key, v.value}
+		if stats != nil {
+			row = append(row, statsColumns(stats[v.key], float64(singles[v.key].cardinalityPer))...)
+		}
+		t.AppendRow(row)
 	}
 
 	t.AppendSeparator()
@@ -192,19 +324,27 @@ func dumpCardinalityInfoWithoutLabels(metric string, cardinality uint64, labels
 	fmt.Println()
 }
 
-func dumpCardinalityPer(metric string, labelInfo labelsCardinalityInfo, action, format string) {
+func dumpCardinalityPer(metric string, labelInfo labelsCardinalityInfo, costs map[string]footprint, action, format string) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"Cardinality % contribution"})
 	t.AppendSeparator()
-	t.AppendHeader(getHeaders(2, action))
+	if costs == nil {
+		t.AppendHeader(getHeaders(2, action))
+	} else {
+		t.AppendHeader(append(getHeaders(2, action), costHeaders()...))
+	}
 	lc := sortMap(labelInfo)
 	for _, v := range lc {
-		if action == "" {
-			t.AppendRow([]interface{}{strings.ReplaceAll(v.key, ",", " -"), labelInfo[v.key].cardinalityPer})
-		} else {
-			t.AppendRow([]interface{}{strings.ReplaceAll(v.key, ",", " -"), labelInfo[v.key].cardinalityPer, labelInfo[v.key].duplicateExists})
+		row := table.Row{strings.ReplaceAll(v.key, ",", " -"), labelInfo[v.key].cardinalityPer}
+		if action != "" {
+			row = append(row, labelInfo[v.key].duplicateExists, blockedVerdict(labelInfo[v.key]), dashboardsVerdict(labelInfo[v.key]))
+		}
+
+		if costs != nil {
+			row = append(row, costColumns(costs[v.key])...)
 		}
+		t.AppendRow(row)
 	}
 
 	t.SetStyle(table.StyleRounded)
@@ -234,3 +374,641 @@ func dumpSystemView(totalSeries uint64, arr []metricSeriesCount, format string)
 	}
 	fmt.Println()
 }
+
+func dumpDropPlans(metric string, cardinality uint64, target int64, plans []dropPlan, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Metric", metric})
+	t.AppendHeader(table.Row{"Cardinality", cardinality})
+	t.AppendHeader(table.Row{"Target", target})
+	t.AppendHeader(table.Row{"Rank", "Drop Labels", "Resulting Series", "Reduction %", "Duplicate Labels Exists"})
+	t.AppendSeparator()
+	for i := range plans {
+		// series over the window may exceed today's cardinality
+		per := 0.0
+		if plans[i].series < cardinality {
+			per = round2(float64(cardinality-plans[i].series) * 100 / float64(cardinality))
+		}
+		t.AppendRow([]interface{}{i + 1, strings.Join(plans[i].labels, " - "), plans[i].series, per, plans[i].duplicateExists})
+	}
+
+	t.AppendSeparator()
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpRelationMatrix(metric string, labels []string, relations []labelRelation, format string) {
+	rel := map[string]string{}
+	for _, r := range relations {
+		rel[r.labelA+"\x00"+r.labelB] = r.relation
+		switch r.relation {
+		case oneToMany:
+			rel[r.labelB+"\x00"+r.labelA] = manyToOne
+		case manyToOne:
+			rel[r.labelB+"\x00"+r.labelA] = oneToMany
+		default:
+			rel[r.labelB+"\x00"+r.labelA] = r.relation
+		}
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Metric", metric})
+	header := table.Row{"Label"}
+	for _, l := range labels {
+		header = append(header, l)
+	}
+	t.AppendHeader(header)
+	t.AppendSeparator()
+	for _, row := range labels {
+		r := table.Row{row}
+		for _, col := range labels {
+			if row == col {
+				r = append(r, "-")
+				continue
+			}
+			r = append(r, rel[row+"\x00"+col])
+		}
+		t.AppendRow(r)
+	}
+
+	t.AppendSeparator()
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpRelations(relations []labelRelation, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Label A", "Label B", "Unique A", "Unique B", "Unique (A, B)", "Relation"})
+	t.AppendSeparator()
+	for _, r := range relations {
+		t.AppendRow([]interface{}{r.labelA, r.labelB, r.uniqueA, r.uniqueB, r.uniqueAB, r.relation})
+	}
+
+	t.AppendSeparator()
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpRedundantLabels(relations []labelRelation, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Redundant labels, can be dropped without any change in cardinality"})
+	t.AppendHeader(table.Row{"Labels", "Relation", "Suggestion"})
+	t.AppendSeparator()
+	for _, r := range relations {
+		note := redundancyNote(r)
+		if note == "" {
+			continue
+		}
+		t.AppendRow([]interface{}{r.labelA + " - " + r.labelB, r.relation, note})
+	}
+
+	t.AppendSeparator()
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpDistributionView(rep distributionReport, format string) {
+	if format == "json" {
+		by, err := json.MarshalIndent(rep, "", "  ")
+		if err != nil {
+			fmt.Println("Error while dumping distribution as json:", err)
+			return
+		}
+		fmt.Println(string(by))
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Metric", rep.Metric})
+	t.AppendHeader(table.Row{"Label", rep.Label})
+	t.AppendHeader(table.Row{"Total Timeseries", rep.TotalSeries})
+	t.AppendHeader(table.Row{"Unique Values", rep.UniqueValues})
+	t.AppendHeader(table.Row{"Fetched Values", rep.FetchedValues})
+	t.AppendSeparator()
+	for _, c := range rep.Coverage {
+		topK := "not covered by fetched values"
+		if c.TopK != -1 {
+			topK = fmt.Sprintf("%d", c.TopK)
+		}
+		t.AppendRow(table.Row{fmt.Sprintf("Top values covering %v%%", c.Share), topK})
+	}
+	t.AppendRow(table.Row{"Tail Values", rep.TailValues})
+	t.AppendRow(table.Row{"Tail Timeseries", rep.TailSeries})
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+
+	t = table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Cumulative share curve"})
+	t.AppendHeader(table.Row{"Rank", "Label Value", "Series", "Share %", "Cumulative %"})
+	t.AppendSeparator()
+	for i, p := range rep.Curve {
+		if i == maxCurveRows {
+			break
+		}
+
+		t.AppendRow(table.Row{p.Rank, p.Value, p.Series, p.Share, p.CumulativeShare})
+		if p.CumulativeShare >= coverageShares[len(coverageShares)-1] {
+			break
+		}
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpTrendView(dates []string, rows []trendRow, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	header := table.Row{"Metric"}
+	for _, d := range dates {
+		header = append(header, d)
+	}
+	header = append(header, "Growth Per Day", "Limit", "Forecast Crossing")
+	t.AppendHeader(header)
+	t.AppendSeparator()
+	for _, r := range rows {
+		row := table.Row{r.name}
+		for _, c := range r.counts {
+			if math.IsNaN(c) {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, uint64(c))
+		}
+		row = append(row, math.Round(r.model.slope*100)/100, r.limit, forecastCrossing(r.counts, r.model, r.limit))
+		t.AppendRow(row)
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpDiffView(name, from, to string, diffs []statDiff, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{name, "Change", from, to, "Growth %"})
+	t.AppendSeparator()
+	for _, d := range diffs {
+		switch d.kind {
+		case diffNew:
+			t.AppendRow(table.Row{d.name, d.kind, "-", d.to, "-"})
+		case diffDisappeared:
+			t.AppendRow(table.Row{d.name, d.kind, d.from, "-", "-"})
+		default:
+			t.AppendRow(table.Row{d.name, d.kind, d.from, d.to, d.change})
+		}
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+// dumpStatView presents stats, a percentage column is added if total is non zero
+func dumpStatView(header table.Row, stats []v1.Stat, total uint64, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(header)
+	for _, s := range stats {
+		if total == 0 {
+			t.AppendRow(table.Row{s.Name, s.Value})
+			continue
+		}
+		t.AppendRow(table.Row{s.Name, s.Value, round2(float64(s.Value) * 100 / float64(total))})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpHeadStatsView(head v1.TSDBHeadStats, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Head Stats"})
+	t.AppendRow(table.Row{"Series", head.NumSeries})
+	t.AppendRow(table.Row{"Label Pairs", head.NumLabelPairs})
+	t.AppendRow(table.Row{"Chunks", head.ChunkCount})
+	t.AppendRow(table.Row{"Min Time", time.UnixMilli(int64(head.MinTime)).UTC().Format(time.RFC3339)})
+	t.AppendRow(table.Row{"Max Time", time.UnixMilli(int64(head.MaxTime)).UTC().Format(time.RFC3339)})
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpSystemRatesView(rates []systemRate, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Stat", "Duration (in seconds)", "Current", "Min", "Avg", "Max", "Trend %"})
+	for _, r := range rates {
+		t.AppendRow(table.Row{r.name, r.duration, round2(r.current), round2(r.min), round2(r.avg), round2(r.max), round2(r.trend)})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpGroupsView(by string, totalSeries uint64, groups []*groupInfo, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Total Timeseries", totalSeries})
+	t.AppendHeader(table.Row{by, "Cardinality", "Cardinality %", "Targets", "Samples Per Scrape", "Series Added Per Scrape"})
+	for _, g := range groups {
+		t.AppendRow(table.Row{g.name, g.series, g.percentage, g.targets, g.samples, g.seriesAdded})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpTargetsView(title, by string, targets []*targetInfo, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{title})
+	t.AppendHeader(table.Row{by, "Job", "Instance", "Samples Per Scrape", "Series Added Per Scrape", "Size vs Median", "Outlier",
+		"Health", "Last Scrape Duration(in seconds)", "Scrape URL"})
+	for _, tg := range targets {
+		t.AppendRow(table.Row{tg.group, tg.job, tg.instance, tg.samples, tg.seriesAdded, tg.sizeVsMedian, tg.outlier,
+			tg.health, round2(tg.lastScrapeDuration), tg.scrapeURL})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpMetricGroupsView(totalSeries uint64, groups []*metricGroupInfo, other uint64, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Total Timeseries", totalSeries})
+	t.AppendHeader(table.Row{"Group", "Metrics", "Cardinality", "Cardinality %"})
+	for _, g := range groups {
+		t.AppendRow(table.Row{g.name, len(g.metrics), g.series, g.percentage})
+	}
+
+	if other != 0 && totalSeries != 0 {
+		t.AppendSeparator()
+		t.AppendRow(table.Row{"other (outside top N)", "-", other, round2(float64(other) * 100 / float64(totalSeries))})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpChargebackView(totalSeries uint64, rows []chargebackRow, unowned, outside chargebackRow, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Total Timeseries", totalSeries})
+	t.AppendHeader(table.Row{"Team", "Series", "Share %", "Previous Day Series", "Growth", "Growth %"})
+	for _, r := range rows {
+		t.AppendRow(table.Row{r.team, r.series, r.percentage, r.prevSeries, r.growth, r.growthShare})
+	}
+
+	t.AppendSeparator()
+	for _, r := range []chargebackRow{unowned, outside} {
+		t.AppendRow(table.Row{r.team, r.series, r.percentage, r.prevSeries, r.growth, r.growthShare})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpFootprintView(metric string, retentionDays int, f footprint, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Metric", metric})
+	t.AppendHeader(table.Row{"Series", "Memory GB", fmt.Sprintf("Disk GB (%d days)", retentionDays), "$/Month"})
+	t.AppendRow(table.Row{f.series, formatCost(f.memoryGB), formatCost(f.diskGB), formatCost(f.monthly)})
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpQuerySourcesView(sources []querySource, namesStatsRecords int, namesStatsAvailable bool, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Source", "Queries", "Unparsed (matched by name)"})
+	for _, s := range sources {
+		t.AppendRow(table.Row{s.name, len(s.queries), s.unparsed})
+	}
+
+	if namesStatsAvailable {
+		t.AppendRow(table.Row{sourceMetricNamesStats, namesStatsRecords, "-"})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpDashboardUsageView(rows []dashboardMetricRow, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Metric", "Dashboards", "Labels Used"})
+	for _, r := range rows {
+		t.AppendRow(table.Row{r.metric, r.dashboards, strings.Join(r.labels, ",")})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpQueryImpactView(metric string, impacts []queryImpact, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Top queries referencing " + metric})
+	t.AppendHeader(table.Row{"Label", "Query", "Uses Label", "Count", "Average Response Time(in seconds)", "Estimated Speed Up", "Estimated Response Time(in seconds)"})
+	prev := ""
+	for _, q := range impacts {
+		if prev != "" && prev != q.labels {
+			t.AppendSeparator()
+		}
+		prev = q.labels
+		if q.omitted != 0 {
+			t.AppendRow(table.Row{strings.ReplaceAll(q.labels, ",", " -"), fmt.Sprintf("%d more queries not using the label", q.omitted), false, "-", "-", "-", "-"})
+			continue
+		}
+		t.AppendRow(table.Row{strings.ReplaceAll(q.labels, ",", " -"), q.query, q.usesLabel, q.count, q.avgDuration, fmt.Sprintf("%vx", q.speedUp), q.after})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpRuleSuggestionsView(suggestions []*ruleSuggestion, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Suggested recording rules"})
+	t.AppendHeader(table.Row{"Rule", "Expression", "Queries", "Count", "Query Time(in seconds)", "Series Read", "New Series", "Estimated Time Saved(in seconds)"})
+	for _, s := range suggestions {
+		queries := make([]string, len(s.queries))
+		for i := range s.queries {
+			queries[i] = s.queries[i].query
+		}
+		t.AppendRow(table.Row{s.name, s.expr, strings.Join(queries, "\n"), s.count, round2(s.queryTime), s.inputSeries, s.outputSeries, s.timeSaved})
+		t.AppendSeparator()
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpRuleCostsView(costs []*ruleCost, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Rule evaluation cost"})
+	t.AppendHeader(table.Row{"Group", "Rule", "Type", "Health", "Evaluation Time(in seconds)", "Last Evaluation", "Series Read", "Series Recorded", "Output/Input", "Useless Aggregation"})
+	for _, c := range costs {
+		recorded, ratio, useless := interface{}("-"), interface{}("-"), "-"
+		if c.kind == recordingRule {
+			recorded, ratio, useless = c.outputSeries, c.ratio, "no"
+			if c.useless {
+				useless = "yes"
+			}
+			if c.outputUnknown {
+				recorded, ratio, useless = "unknown", "-", "unknown"
+			}
+		}
+		t.AppendRow(table.Row{c.group, c.name, c.kind, c.health, c.evaluationTime, c.lastEvaluation, c.inputSeries, recorded, ratio, useless})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpRuleCheckView(rules []*ruleCheck, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Broken rules"})
+	t.AppendHeader(table.Row{"Group", "Rule", "Type", "Dead Selectors", "Missing Grouping Labels"})
+	for _, r := range rules {
+		if r.unparsed {
+			t.AppendRow(table.Row{r.group, r.name, r.kind, "expression couldn't be parsed", "-"})
+			t.AppendSeparator()
+			continue
+		}
+		t.AppendRow(table.Row{r.group, r.name, r.kind, strings.Join(r.deadSelectors, "\n"), strings.Join(r.missingLabels, "\n")})
+		t.AppendSeparator()
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpHistogramBucketsView(metric string, total float64, buckets []*histogramBucket, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Histogram", metric})
+	t.AppendHeader(table.Row{"Observations", total})
+	t.AppendHeader(table.Row{"Le", "Cumulative Count", "Observations", "Observations %", "Verdict", "Keep"})
+	for _, b := range buckets {
+		verdict, keep := b.verdict, "no"
+		if verdict == "" {
+			verdict = "-"
+		}
+		if b.keep {
+			keep = "yes"
+		}
+		t.AppendRow(table.Row{b.le, round2(b.cumulative), round2(b.observations), b.share, verdict, keep})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpHistogramSeriesView(labelSets uint64, estimates []histogramSeries, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Label Sets", labelSets})
+	t.AppendHeader(table.Row{"Layout", "Buckets", "Series", "Saving %"})
+	for _, e := range estimates {
+		t.AppendRow(table.Row{e.layout, e.buckets, e.series, e.saving})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpBucketLayoutsView(metric string, layouts []*bucketLayout, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Bucket layouts of", metric})
+	t.AppendHeader(table.Row{"Buckets", "Instances", "Status", "Missing Buckets", "Extra Buckets", "+Inf"})
+	for _, l := range layouts {
+		status, inf := "mismatch", "present"
+		if l.reference {
+			status = "reference"
+		}
+		if l.noInf {
+			inf = "missing"
+		}
+		t.AppendRow(table.Row{strings.Join(l.les, ", "), strings.Join(l.instances, "\n"), status, strings.Join(l.missing, ", "), strings.Join(l.extra, ", "), inf})
+		t.AppendSeparator()
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpLayoutChangesView(changes []layoutChange, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Bucket layout changes within window"})
+	t.AppendHeader(table.Row{"Instance", "Buckets No Longer Exposed"})
+	for _, c := range changes {
+		t.AppendRow(table.Row{c.instance, strings.Join(c.retired, ", ")})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
+
+func dumpNonMonotonicBucketsView(series []nonMonotonicSeries, format string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Non monotonic buckets"})
+	t.AppendHeader(table.Row{"Instance", "Series", "Decreases At Le"})
+	for _, s := range series {
+		t.AppendRow(table.Row{s.instance, s.series, s.le})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	if format == "csv" {
+		t.RenderCSV()
+	} else {
+		t.Render()
+	}
+	fmt.Println()
+}
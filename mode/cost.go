@@ -0,0 +1,110 @@
+package mode
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	bytesInGB = 1 << 30
+	// memory of every label name is needed to find memory per series
+	memoryLabelsTopN = "100000"
+)
+
+// CostModel converts series and samples into memory, disk and money
+type CostModel struct {
+	// bytes taken by a series in index and memory
+	BytesPerSeries float64 `yaml:"bytes_per_series" mapstructure:"bytes_per_series"`
+	// bytes taken by a compressed sample on disk
+	BytesPerSample float64 `yaml:"bytes_per_sample" mapstructure:"bytes_per_sample"`
+	// memory and disk are priced separately, memory costs far more per GB
+	MemoryPricePerGBMonth float64 `yaml:"memory_price_per_gb_month" mapstructure:"memory_price_per_gb_month"`
+	DiskPricePerGBMonth   float64 `yaml:"disk_price_per_gb_month" mapstructure:"disk_price_per_gb_month"`
+	RetentionDays         int     `yaml:"retention_days" mapstructure:"retention_days"`
+	// used when ingestion rate of a metric can't be found, in seconds
+	ScrapeInterval int `yaml:"scrape_interval" mapstructure:"scrape_interval"`
+}
+
+// footprint is what a set of series costs, or saves if it is dropped
+type footprint struct {
+	series   uint64
+	memoryGB float64
+	diskGB   float64
+	monthly  float64
+}
+
+type costEstimator struct {
+	model          CostModel
+	bytesPerSeries float64
+	// samples ingested per second by a single series
+	sampleRate float64
+}
+
+// newCostEstimator derives bytes per series from memory reported by the backend and
+// sample rate from ingestion rate of the metric, falling back to the cost model
+func newCostEstimator(v1api v1.API, model CostModel, metric string, cardinality uint64, duration, lag int) costEstimator {
+	e := costEstimator{model: model, bytesPerSeries: model.BytesPerSeries}
+	if model.ScrapeInterval > 0 {
+		e.sampleRate = 1 / float64(model.ScrapeInterval)
+	}
+
+	// memory by label name is reported by prometheus only, along with series in head
+	r, err := apiclient.TopMetrics(v1api, memoryLabelsTopN, "")
+	if err != nil {
+		fmt.Println("Error while fetching memory by label name, using bytes per series from cost model:", err)
+	} else if r.HeadStats.NumSeries > 0 {
+		memory := uint64(0)
+		for _, s := range r.MemoryInBytesByLabelName {
+			memory += s.Value
+		}
+
+		if memory != 0 {
+			e.bytesPerSeries = float64(memory) / float64(r.HeadStats.NumSeries)
+		}
+	}
+
+	rate, err := apiclient.IngestionRate(v1api, metric, duration, lag)
+	if err != nil {
+		fmt.Println("Error while finding ingestion rate, using scrape interval from cost model:", err)
+	} else if rate > 0 && cardinality != 0 {
+		e.sampleRate = rate / float64(cardinality)
+	}
+
+	return e
+}
+
+// estimate returns memory, disk over retention period and monthly cost of series
+func (e costEstimator) estimate(series uint64) footprint {
+	f := footprint{series: series}
+	f.memoryGB = float64(series) * e.bytesPerSeries / bytesInGB
+
+	samples := float64(series) * e.sampleRate * float64(e.model.RetentionDays) * 24 * 3600
+	f.diskGB = samples * e.model.BytesPerSample / bytesInGB
+
+	f.monthly = f.memoryGB*e.model.MemoryPricePerGBMonth + f.diskGB*e.model.DiskPricePerGBMonth
+	return f
+}
+
+// formatCost rounds GB and money for display, values below 1 keep 2 significant
+// digits so that savings of a few thousand series don't show as 0
+func formatCost(v float64) string {
+	if v != 0 && math.Abs(v) < 1 {
+		scale := math.Pow(10, 1-math.Floor(math.Log10(math.Abs(v))))
+		v = math.Round(v*scale) / scale
+	} else {
+		v = round2(v)
+	}
+
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// savings estimates footprint of series removed by a label whose cardinality
+// contribution is per % of the metric
+func (e costEstimator) savings(cardinality uint64, per int) footprint {
+	return e.estimate(cardinality * uint64(per) / 100)
+}
@@ -0,0 +1,283 @@
+package mode
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// grafanaVarPlaceholder replaces grafana variables before parsing, matchers
+// whose value contains it are turned into wildcard matchers
+const grafanaVarPlaceholder = "__grafana_var__"
+
+// grafanaVarRe matches $var, ${var}, ${var:format}, [[var]] and [[var:format]],
+// $1 style references of label_replace are left alone
+var grafanaVarRe = regexp.MustCompile(`\$\{[^}]+\}|\[\[[^\]]+\]\]|\$[a-zA-Z_]\w*`)
+
+// labelValuesRe matches variable queries like label_values(metric, label) and label_values(label)
+var labelValuesRe = regexp.MustCompile(`^\s*label_values\s*\(\s*(?:(.+?)\s*,\s*)?([a-zA-Z_]\w*)\s*\)\s*$`)
+
+var queryResultRe = regexp.MustCompile(`^\s*query_result\s*\((.*)\)\s*$`)
+
+// dashboardQuery is a query found in a grafana dashboard
+type dashboardQuery struct {
+	dashboard string
+	// panel or variable the query belongs to
+	source string
+	expr   string
+}
+
+type grafanaTarget struct {
+	Expr       string          `json:"expr"`
+	Datasource json.RawMessage `json:"datasource"`
+}
+
+type grafanaPanel struct {
+	Title   string          `json:"title"`
+	Targets []grafanaTarget `json:"targets"`
+	// panels of a row, since grafana 5
+	Panels []grafanaPanel `json:"panels"`
+}
+
+type grafanaDashboard struct {
+	Title  string         `json:"title"`
+	Panels []grafanaPanel `json:"panels"`
+	// rows of dashboards before grafana 5
+	Rows []struct {
+		Title  string         `json:"title"`
+		Panels []grafanaPanel `json:"panels"`
+	} `json:"rows"`
+	Templating struct {
+		List []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			// either a string or an object with query field, depending on grafana version
+			Query json.RawMessage `json:"query"`
+		} `json:"list"`
+	} `json:"templating"`
+}
+
+// metricUsage is the dashboards using a metric and dashboards depending on each of its labels
+type metricUsage struct {
+	labels     map[string]map[string]bool
+	dashboards map[string]bool
+}
+
+// dashboardIndex maps metrics to labels used by dashboards
+type dashboardIndex struct {
+	metrics  map[string]*metricUsage
+	queries  int
+	unparsed int
+}
+
+// resolveVariables replaces grafana variables, so that the query can be parsed
+func resolveVariables(expr string) string {
+	return grafanaVarRe.ReplaceAllString(expr, grafanaVarPlaceholder)
+}
+
+// widenMatchers turns matchers on variables into regex matchers with the
+// variable as wildcard, e.g. job="$job" becomes job=~".*"
+func widenMatchers(expr exprNode) {
+	for _, s := range querySelectors(expr) {
+		for i, m := range s.matchers {
+			if !strings.Contains(m.value, grafanaVarPlaceholder) {
+				continue
+			}
+
+			value := m.value
+			op := m.op
+			switch m.op {
+			case "=", "!=":
+				parts := strings.Split(value, grafanaVarPlaceholder)
+				for j := range parts {
+					parts[j] = regexp.QuoteMeta(parts[j])
+				}
+				value = strings.Join(parts, grafanaVarPlaceholder)
+				op = map[string]string{"=": "=~", "!=": "!~"}[m.op]
+			}
+
+			widened, err := newLabelMatcher(m.name, op, strings.ReplaceAll(value, grafanaVarPlaceholder, ".*"))
+			if err == nil {
+				s.matchers[i] = widened
+			}
+		}
+	}
+}
+
+func isLokiTarget(t grafanaTarget) bool {
+	var ds struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(t.Datasource, &ds) == nil && ds.Type == "loki"
+}
+
+func collectPanelQueries(dashboard string, panels []grafanaPanel, queries *[]dashboardQuery) {
+	for _, p := range panels {
+		for _, t := range p.Targets {
+			if strings.TrimSpace(t.Expr) == "" || isLokiTarget(t) {
+				continue
+			}
+			*queries = append(*queries, dashboardQuery{dashboard: dashboard, source: "panel: " + p.Title, expr: t.Expr})
+		}
+
+		// panels nested in rows
+		collectPanelQueries(dashboard, p.Panels, queries)
+	}
+}
+
+// variableQuery converts query of a template variable to a PromQL query
+func variableQuery(raw json.RawMessage) string {
+	var q string
+	if err := json.Unmarshal(raw, &q); err != nil {
+		var obj struct {
+			Query string `json:"query"`
+		}
+		if json.Unmarshal(raw, &obj) != nil {
+			return ""
+		}
+		q = obj.Query
+	}
+
+	if parts := labelValuesRe.FindStringSubmatch(q); parts != nil {
+		if parts[1] == "" {
+			return ""
+		}
+		// the label of label_values is used for grouping
+		return fmt.Sprintf("group by (%s) (%s)", parts[2], parts[1])
+	}
+
+	if parts := queryResultRe.FindStringSubmatch(q); parts != nil {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// dashboardQueries reads queries of panels, nested rows and template variables
+// of all grafana dashboard JSON files under dir
+func dashboardQueries(dir string) ([]dashboardQuery, error) {
+	queries := []dashboardQuery{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var d grafanaDashboard
+		if err := json.Unmarshal(b, &d); err != nil {
+			return fmt.Errorf("invalid dashboard %s: %w", path, err)
+		}
+
+		name := d.Title
+		if name == "" {
+			name = path
+		}
+
+		collectPanelQueries(name, d.Panels, &queries)
+		for _, r := range d.Rows {
+			collectPanelQueries(name, r.Panels, &queries)
+		}
+
+		for _, v := range d.Templating.List {
+			if v.Type != "" && v.Type != "query" {
+				continue
+			}
+
+			if q := variableQuery(v.Query); q != "" {
+				queries = append(queries, dashboardQuery{dashboard: name, source: "variable: " + v.Name, expr: q})
+			}
+		}
+
+		return nil
+	})
+
+	return queries, err
+}
+
+// parseDashboardQuery parses a query after resolving its variables to wildcards
+func parseDashboardQuery(q string) (exprNode, error) {
+	expr, err := parseQuery(resolveVariables(q))
+	if err != nil {
+		return nil, err
+	}
+
+	widenMatchers(expr)
+	return expr, nil
+}
+
+func buildDashboardIndex(queries []dashboardQuery) dashboardIndex {
+	idx := dashboardIndex{metrics: map[string]*metricUsage{}, queries: len(queries)}
+	for _, q := range queries {
+		expr, err := parseDashboardQuery(q.expr)
+		if err != nil {
+			idx.unparsed++
+			continue
+		}
+
+		for _, s := range querySelectors(expr) {
+			name := s.metricName()
+			if name == "" || name == grafanaVarPlaceholder {
+				continue
+			}
+
+			u, ok := idx.metrics[name]
+			if !ok {
+				u = &metricUsage{labels: map[string]map[string]bool{}, dashboards: map[string]bool{}}
+				idx.metrics[name] = u
+			}
+			u.dashboards[q.dashboard] = true
+
+			_, labels := queryLabelUsage(expr, name)
+			for l := range labels {
+				if l == grafanaVarPlaceholder {
+					continue
+				}
+
+				if u.labels[l] == nil {
+					u.labels[l] = map[string]bool{}
+				}
+				u.labels[l][q.dashboard] = true
+			}
+		}
+	}
+
+	return idx
+}
+
+// dashboardsUsingLabels returns dashboards which depend on any of the labels of the metric
+func (idx dashboardIndex) dashboardsUsingLabels(metric string, labels []string) []string {
+	u, ok := idx.metrics[metric]
+	if !ok {
+		return nil
+	}
+
+	dashboards := map[string]bool{}
+	for _, l := range labels {
+		for d := range u.labels[l] {
+			dashboards[d] = true
+		}
+	}
+
+	return sortedKeys(dashboards)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
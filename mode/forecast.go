@@ -0,0 +1,131 @@
+package mode
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Limits are the series counts beyond which a metric or the whole TSDB is
+// considered to be in trouble, used for forecasting
+type Limits struct {
+	TotalSeries  uint64            `yaml:"total_series" mapstructure:"total_series"`
+	MetricSeries uint64            `yaml:"metric_series" mapstructure:"metric_series"`
+	Metrics      map[string]uint64 `yaml:"metrics" mapstructure:"metrics"`
+}
+
+// metricLimit returns the limit specific to the metric, falling back to the
+// limit for all metrics
+func (l Limits) metricLimit(metric string) uint64 {
+	if v, ok := l.Metrics[metric]; ok {
+		return v
+	}
+
+	return l.MetricSeries
+}
+
+// growthModel is a linear fit of series count over days
+type growthModel struct {
+	slope     float64
+	intercept float64
+	points    int
+}
+
+type trendRow struct {
+	name   string
+	counts []float64
+	model  growthModel
+	limit  uint64
+}
+
+// lastNDates returns N dates in YYYY-MM-DD format ending yesterday, oldest first.
+// Today is left out as its series count is partial and would bend the fit down.
+func lastNDates(n int) []string {
+	now := time.Now().UTC()
+	dates := make([]string, n)
+	for i := 0; i < n; i++ {
+		dates[n-1-i] = now.AddDate(0, 0, -i-1).Format("2006-01-02")
+	}
+
+	return dates
+}
+
+// fitGrowth fits series count against day index using least squares,
+// days with missing count (NaN) are ignored
+func fitGrowth(counts []float64) growthModel {
+	var (
+		n            float64
+		sumX, sumY   float64
+		sumXY, sumXX float64
+		g            = growthModel{}
+	)
+
+	for x, y := range counts {
+		if math.IsNaN(y) {
+			continue
+		}
+		n++
+		sumX += float64(x)
+		sumY += y
+		sumXY += float64(x) * y
+		sumXX += float64(x) * float64(x)
+	}
+
+	g.points = int(n)
+	if n < 2 {
+		return g
+	}
+
+	g.slope = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	g.intercept = (sumY - g.slope*sumX) / n
+	return g
+}
+
+// forecastCrossing returns the date on which series count is expected to cross
+// the limit, "crossed" if it already has and "-" if it never will
+func forecastCrossing(counts []float64, g growthModel, limit uint64) string {
+	if limit == 0 {
+		return "no limit"
+	}
+
+	last := math.NaN()
+	for i := len(counts) - 1; i >= 0; i-- {
+		if !math.IsNaN(counts[i]) {
+			last = counts[i]
+			break
+		}
+	}
+
+	if !math.IsNaN(last) && last >= float64(limit) {
+		return "crossed"
+	}
+
+	if g.points < 2 || g.slope <= 0 {
+		return "-"
+	}
+
+	// day index at which fitted line reaches the limit, relative to today as
+	// counts end yesterday
+	days := (float64(limit)-g.intercept)/g.slope - float64(len(counts))
+	if days < 0 {
+		days = 0
+	}
+
+	return time.Now().UTC().AddDate(0, 0, int(math.Ceil(days))).Format("2006-01-02")
+}
+
+func sortTrendRows(rows []trendRow) {
+	// sort by latest series count, so that biggest metrics come first
+	latest := func(r trendRow) float64 {
+		for i := len(r.counts) - 1; i >= 0; i-- {
+			if !math.IsNaN(r.counts[i]) {
+				return r.counts[i]
+			}
+		}
+		return 0
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return latest(rows[i]) > latest(rows[j])
+	})
+}
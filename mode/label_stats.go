@@ -0,0 +1,108 @@
+package mode
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// share of top values considered for pareto statistics
+const paretoTopShare = 0.2
+
+type distributionStats struct {
+	values      int
+	entropy     float64
+	normEntropy float64
+	gini        float64
+	topShare    float64
+}
+
+// labelDistribution returns series count of every value of the label. Status api is asked for
+// all unique values, if it returns less than that then series api is used to count them.
+func labelDistribution(v1api v1.API, metric, label string, uniqueCount int, date string, start, end time.Time) ([]uint64, error) {
+	counts := []uint64{}
+	if uniqueCount > 0 {
+		r, err := apiclient.MetricInfo(v1api, metric, label, strconv.Itoa(uniqueCount), date)
+		if err == nil && len(r.SeriesCountByFocusLabelValue) >= uniqueCount {
+			for i := range r.SeriesCountByFocusLabelValue {
+				counts = append(counts, r.SeriesCountByFocusLabelValue[i].Value)
+			}
+			return counts, nil
+		}
+	}
+
+	m, err := apiclient.SeriesCountByLabelValue(v1api, metric, label, start, end)
+	if err != nil {
+		return counts, err
+	}
+
+	for _, v := range m {
+		counts = append(counts, v)
+	}
+
+	return counts, nil
+}
+
+// distributionOf finds shannon entropy, gini coefficient and share of series held
+// by top 20% of values from series count of each label value
+func distributionOf(counts []uint64) distributionStats {
+	st := distributionStats{values: len(counts)}
+
+	total := 0.0
+	for _, c := range counts {
+		total += float64(c)
+	}
+
+	if total == 0 {
+		return st
+	}
+
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / total
+		st.entropy -= p * math.Log2(p)
+	}
+
+	if len(counts) > 1 {
+		st.normEntropy = st.entropy / math.Log2(float64(len(counts)))
+	}
+
+	sorted := append([]uint64{}, counts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	// gini = sum((2i - n - 1) * x_i) / (n * sum(x)), with x sorted in increasing order
+	n := float64(len(sorted))
+	weighted := 0.0
+	for i, c := range sorted {
+		weighted += (2*float64(i+1) - n - 1) * float64(c)
+	}
+	st.gini = weighted / (n * total)
+
+	top := int(math.Ceil(n * paretoTopShare))
+	topSum := 0.0
+	for i := len(sorted) - top; i < len(sorted); i++ {
+		topSum += float64(sorted[i])
+	}
+	st.topShare = topSum * 100 / total
+
+	return st
+}
+
+// dropScore weighs the cost of a label against the information it carries, a label
+// which costs a lot but whose series are concentrated on a few values scores high
+func dropScore(cost float64, st distributionStats) float64 {
+	return cost / (1 + st.entropy)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
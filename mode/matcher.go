@@ -0,0 +1,59 @@
+package mode
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// matcherRe parses a single label matcher like team="payments" or namespace=~"pay-.*"
+var matcherRe = regexp.MustCompile(`^\s*([a-zA-Z_]\w*)\s*(=~|!~|!=|=)\s*"(.*)"\s*$`)
+
+type labelMatcher struct {
+	name  string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func parseMatcher(s string) (labelMatcher, error) {
+	parts := matcherRe.FindStringSubmatch(s)
+	if parts == nil {
+		return labelMatcher{}, fmt.Errorf("invalid matcher %q, expected format is label=\"value\"", s)
+	}
+
+	return newLabelMatcher(parts[1], parts[2], parts[3])
+}
+
+func newLabelMatcher(name, op, value string) (labelMatcher, error) {
+	m := labelMatcher{name: name, op: op, value: value}
+	if m.op == "=~" || m.op == "!~" {
+		// regex matchers are fully anchored, same as in PromQL
+		re, err := regexp.Compile("^(?:" + m.value + ")$")
+		if err != nil {
+			return labelMatcher{}, fmt.Errorf("invalid regex in matcher %s: %w", m, err)
+		}
+		m.re = re
+	}
+
+	return m, nil
+}
+
+// matches reports if the label value satisfies the matcher
+func (m labelMatcher) matches(value string) bool {
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.re.MatchString(value)
+	case "!~":
+		return !m.re.MatchString(value)
+	}
+
+	return false
+}
+
+func (m labelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.name, m.op, m.value)
+}
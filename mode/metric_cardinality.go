@@ -27,8 +27,25 @@ type CardinalityFlag struct {
 	AggregateAction            bool
 	SplitAction                bool
 	DisableRelativeCardinality bool
+	QueryBudget                int
+	Target                     int64
+	ExhaustiveLimit            int
+	MaxPlans                   int
+	AllowDuplicates            bool
+	Entropy                    bool
+	Cost                       bool
+	CostModel                  CostModel
+	RuleFiles                  []string
+	DashboardsDir              string
+	TopQueries                 bool
+	QueriesTopN                string
+	TopNMaxLifeTime            string
 }
 
+// label combinations at or above this cardinality contribution already collapse
+// almost every series, combinations built on top of them can't reveal anything more
+const saturatedContributionPer = 99
+
 type cardinalityDetails struct {
 	cardinality uint64
 	labelInfo   labelMap
@@ -62,21 +79,76 @@ func (r *RWMap) SetDropActionInfo(key string, labelExists bool) {
 	r.m[key] = v
 }
 
-func createPairs(labels []string, labelCount int) []string {
-	pairs := []string{}
-	if labelCount == 1 {
-		return labels
+// hasSaturatedSubset reports if any subset of comb with one label less already
+// has ~100% cardinality contribution or was never evaluated (pruned or out of budget).
+// Dropping a superset of it can't collapse more series, so evaluating it only
+// spends the query budget. Subsets with ~0% contribution are kept on purpose,
+// labels like cluster/region/zone are only redundant together.
+func hasSaturatedSubset(cMap *RWMap, comb []string) bool {
+	for i := range comb {
+		sub := append(append([]string{}, comb[:i]...), comb[i+1:]...)
+
+		cMap.RLock()
+		v, ok := cMap.m[strings.Join(sub, ", ")]
+		cMap.RUnlock()
+		if !ok || v.cardinalityPer >= saturatedContributionPer {
+			return true
+		}
 	}
 
-	for i := 0; i < len(labels)-1; i++ {
-		for j := i + 1; j < len(labels); j++ {
-			pairs = append(pairs, labels[i]+", "+labels[j])
+	return false
+}
+
+// candidateCombinations returns combinations of k labels worth evaluating given
+// results of combinations of k-1 labels
+func candidateCombinations(cMap *RWMap, labels []string, k int) []string {
+	pairs := []string{}
+	for _, comb := range combinations(labels, k) {
+		if k > 1 && hasSaturatedSubset(cMap, comb) {
+			continue
 		}
+		pairs = append(pairs, strings.Join(comb, ", "))
 	}
 
 	return pairs
 }
 
+// combinations returns all unique combinations of k labels, preserving the
+// order in which labels were provided
+func combinations(labels []string, k int) [][]string {
+	combs := [][]string{}
+	if k <= 0 || k > len(labels) {
+		return combs
+	}
+
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+
+	for {
+		comb := make([]string, k)
+		for i := range idx {
+			comb[i] = labels[idx[i]]
+		}
+		combs = append(combs, comb)
+
+		// advance the right most index which still has room to move
+		i := k - 1
+		for i >= 0 && idx[i] == len(labels)-k+i {
+			i--
+		}
+		if i < 0 {
+			return combs
+		}
+
+		idx[i]++
+		for j := i + 1; j < k; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+}
+
 func CardinalityInvoke(dataSource string, cFlag CardinalityFlag) {
 	var (
 		wg = &sync.WaitGroup{}
@@ -118,6 +190,16 @@ func CardinalityInvoke(dataSource string, cFlag CardinalityFlag) {
 		return
 	}
 
+	// savings are estimated against the whole metric, so estimator is built
+	// before relative cardinality narrows down the metric
+	var estimator costEstimator
+	if cFlag.Cost {
+		estimator = newCostEstimator(v1api, cFlag.CostModel, cFlag.Metric, cardinality, cFlag.CardinalityPerDuration, cFlag.Lag)
+	}
+
+	// relative cardinality adds a filter to the metric, rules need the plain name
+	metricName := selectorMetricName(cFlag.Metric)
+
 	// In case of high cardinality pick the filter variable with smallest Cardinality
 	// use that as a filter in base metric to find cardinality contribution, if Cardinality
 	// is with in limit then no need to use filter
@@ -176,63 +258,179 @@ func CardinalityInvoke(dataSource string, cFlag CardinalityFlag) {
 		labelsToConsider = cFlag.Label
 	}
 
-	// create unique pairs as per label count
-	pairs := createPairs(labelsToConsider, cFlag.LabelCount)
 	cMap := &RWMap{m: labelsCardinalityInfo{}}
+	evaluate := func(pairs []string) {
+		for p := range pairs {
+			wg.Add(1)
+			go func(p int) {
+				defer wg.Done()
+				// If the diff between current time and start of the day time in UTC is less than 12 hrs then use the diff instead of 12 hours
+				now := time.Now().UTC()
+				startOfDayTime := time.Date(
+					now.Year(), now.Month(),
+					now.Day(), 0, 0, 0, 0, time.UTC).Unix()
+				currentTime := now.Unix()
+				cardinalityDuration := int(currentTime - startOfDayTime)
+				if cardinalityDuration > cFlag.CardinalityPerDuration {
+					cardinalityDuration = cFlag.CardinalityPerDuration
+				}
 
-	// if label count < no of explicit labels provided then include
-	// a combination of all labels also
-	if len(cFlag.Label) != 0 && cFlag.LabelCount < len(cFlag.Label) {
-		pairs = append(pairs, strings.Join(labelsToConsider, ","))
-	}
-
-	for p := range pairs {
-		wg.Add(1)
-		go func(p int) {
-			defer wg.Done()
-			// If the diff between current time and start of the day time in UTC is less than 12 hrs then use the diff instead of 12 hours
-			now := time.Now().UTC()
-			startOfDayTime := time.Date(
-				now.Year(), now.Month(),
-				now.Day(), 0, 0, 0, 0, time.UTC).Unix()
-			currentTime := now.Unix()
-			cardinalityDuration := int(currentTime - startOfDayTime)
-			if cardinalityDuration > cFlag.CardinalityPerDuration {
-				cardinalityDuration = cFlag.CardinalityPerDuration
-			}
+				r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, pairs[p], apiclient.LabelCardinalityStr)
+				if err != nil {
+					fmt.Println("Error while finding cardinality:", err)
+				}
 
-			r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, pairs[p], apiclient.LabelCardinalityStr)
-			if err != nil {
-				fmt.Println("Error while finding cardinality:", err)
-			}
+				per := int((cd.cardinality - uint64(r)) * 100 / cd.cardinality)
 
-			per := int((cd.cardinality - uint64(r)) * 100 / cd.cardinality)
+				cMap.Set(pairs[p], labelInfo{uniqueCount: cd.labelInfo[pairs[p]].uniqueCount, cardinalityPer: per})
 
-			cMap.Set(pairs[p], labelInfo{uniqueCount: cd.labelInfo[pairs[p]].uniqueCount, cardinalityPer: per})
+				if cFlag.DropAction {
+					r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, pairs[p], apiclient.DuplicatesLabelsStr)
+					if err != nil {
+						fmt.Println("Error while finding duplicate labels exists:", err)
+					}
 
-			if cFlag.DropAction {
-				r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, pairs[p], apiclient.DuplicatesLabelsStr)
-				if err != nil {
-					fmt.Println("Error while finding duplicate labels exists:", err)
+					cMap.SetDropActionInfo(pairs[p], r == 1)
 				}
+			}(p)
+		}
 
-				cMap.SetDropActionInfo(pairs[p], r == 1)
+		wg.Wait()
+	}
+
+	// every combination costs a query, and one more if drop action is selected
+	queriesPerPair := 1
+	if cFlag.DropAction {
+		queriesPerPair = 2
+	}
+	budget := cFlag.QueryBudget / queriesPerPair
+
+	// combinations are evaluated level by level, so that combinations of k labels
+	// can be pruned using results of combinations of k-1 labels
+	skipped := 0
+	for k := 1; k <= cFlag.LabelCount && k <= len(labelsToConsider); k++ {
+		pairs := candidateCombinations(cMap, labelsToConsider, k)
+
+		// single labels are always evaluated, the budget only limits combinations
+		if k > 1 {
+			if len(pairs) > budget {
+				skipped += len(pairs) - budget
+				pairs = pairs[:budget]
 			}
-		}(p)
+			budget -= len(pairs)
+		}
 
+		evaluate(pairs)
 	}
 
-	wg.Wait()
+	// if label count < no of explicit labels provided then include
+	// a combination of all labels also
+	if len(cFlag.Label) != 0 && cFlag.LabelCount < len(cFlag.Label) {
+		evaluate([]string{strings.Join(labelsToConsider, ", ")})
+	}
+
+	if skipped != 0 {
+		fmt.Printf("Query budget exhausted, %d label combinations were not evaluated, use --query-budget to increase it\n\n", skipped)
+	}
+
+	// combinations of lower levels were only needed for pruning, only combinations
+	// of exactly label count labels are reported
+	level := cFlag.LabelCount
+	if level > len(labelsToConsider) {
+		level = len(labelsToConsider)
+	}
+	singles := labelsCardinalityInfo{}
+	for k, v := range cMap.m {
+		size := len(strings.Split(k, ", "))
+		if size == 1 {
+			singles[k] = v
+		}
+		if size < level {
+			delete(cMap.m, k)
+		}
+	}
+
+	var stats map[string]distributionStats
+	if cFlag.Entropy {
+		stats = map[string]distributionStats{}
+		lock := sync.Mutex{}
+		end := time.Now().Add(-time.Duration(cFlag.Lag) * time.Second)
+		start := end.Add(-time.Duration(cFlag.CardinalityPerDuration) * time.Second)
+		for l := range singles {
+			wg.Add(1)
+			go func(l string) {
+				defer wg.Done()
+				counts, err := labelDistribution(v1api, cFlag.Metric, l, cd.labelInfo[l].uniqueCount, "", start, end)
+				if err != nil {
+					fmt.Println("Error while fetching label value distribution:", err)
+					return
+				}
+
+				lock.Lock()
+				stats[l] = distributionOf(counts)
+				lock.Unlock()
+			}(l)
+		}
+		wg.Wait()
+	}
 
 	action := ""
 	if cFlag.DropAction {
 		action = drop
+
+		rules, err := loadRules(v1api, cFlag.RuleFiles)
+		if err != nil {
+			fmt.Println("Error while fetching rules, drops are not checked against rules:", err)
+		}
+
+		var idx dashboardIndex
+		if cFlag.DashboardsDir != "" {
+			queries, err := dashboardQueries(cFlag.DashboardsDir)
+			if err != nil {
+				fmt.Println("Error while reading dashboards, drops are not checked against dashboards:", err)
+			}
+			idx = buildDashboardIndex(queries)
+		}
+
+		ruleUses := rulesUsingMetric(rules, metricName)
+		for k, v := range cMap.m {
+			v.blockedBy = rulesBlockingDrop(ruleUses, strings.Split(k, ", "))
+			v.dashboards = idx.dashboardsUsingLabels(metricName, strings.Split(k, ", "))
+			cMap.m[k] = v
+		}
+	}
+
+	var impacts []queryImpact
+	if cFlag.DropAction && cFlag.TopQueries {
+		res, err := apiclient.TopQueries(v1api, cFlag.QueriesTopN, cFlag.TopNMaxLifeTime)
+		if err != nil {
+			fmt.Println("Error while fetching top queries:", err)
+		} else {
+			impacts = queryImpacts(mergeTopQueries(res), metricName, cMap.m)
+		}
+	}
+
+	var costs map[string]footprint
+	if cFlag.Cost {
+		costs = map[string]footprint{}
+		for k, v := range cMap.m {
+			costs[k] = estimator.savings(cardinality, v.cardinalityPer)
+		}
+		dumpFootprintView(cFlag.Metric, estimator.model.RetentionDays, estimator.estimate(cardinality), cFlag.DumpAs)
 	}
 
 	if cFlag.LabelCount == 1 {
-		dumpCardinalityInfoPerLabel(cFlag.Metric, cd.cardinality, cMap.m, action, cFlag.DumpAs)
+		dumpCardinalityInfoPerLabel(cFlag.Metric, cd.cardinality, cMap.m, stats, costs, action, cFlag.DumpAs)
 	} else {
-		dumpCardinalityInfoWithoutLabels(cFlag.Metric, cd.cardinality, cd.labelInfo, cFlag.DumpAs)
-		dumpCardinalityPer(cFlag.Metric, cMap.m, action, cFlag.DumpAs)
+		dumpCardinalityInfoWithoutLabels(cFlag.Metric, cd.cardinality, cd.labelInfo, singles, stats, cFlag.DumpAs)
+		dumpCardinalityPer(cFlag.Metric, cMap.m, costs, action, cFlag.DumpAs)
+	}
+
+	if cFlag.DropAction && cFlag.TopQueries {
+		if len(impacts) == 0 {
+			fmt.Println("No top queries reference", metricName)
+			return
+		}
+		dumpQueryImpactView(metricName, impacts, cFlag.DumpAs)
 	}
 }
@@ -0,0 +1,84 @@
+package mode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCandidateCombinations(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		// cardinality contribution of evaluated combinations
+		evaluated map[string]int
+		k         int
+		want      []string
+	}{
+		{
+			// each of cluster, region and zone can be derived from the others,
+			// only dropping all of them together collapses series
+			name:   "jointly redundant labels",
+			labels: []string{"cluster", "region", "zone"},
+			evaluated: map[string]int{
+				"cluster": 0, "region": 0, "zone": 0,
+				"cluster, region": 0, "cluster, zone": 0, "region, zone": 0,
+			},
+			k:    3,
+			want: []string{"cluster, region, zone"},
+		},
+		{
+			name:      "pairs of redundant labels",
+			labels:    []string{"cluster", "region", "zone"},
+			evaluated: map[string]int{"cluster": 0, "region": 0, "zone": 0},
+			k:         2,
+			want:      []string{"cluster, region", "cluster, zone", "region, zone"},
+		},
+		{
+			name:      "saturated subset",
+			labels:    []string{"pod", "job", "zone"},
+			evaluated: map[string]int{"pod": 99, "job": 40, "zone": 0},
+			k:         2,
+			want:      []string{"job, zone"},
+		},
+		{
+			name:      "subset out of budget",
+			labels:    []string{"pod", "job", "zone"},
+			evaluated: map[string]int{"cluster, region": 0, "pod, job": 40, "pod, zone": 30},
+			k:         3,
+			want:      []string{},
+		},
+		{
+			name:      "single labels",
+			labels:    []string{"pod", "job"},
+			evaluated: map[string]int{},
+			k:         1,
+			want:      []string{"pod", "job"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cMap := &RWMap{m: labelsCardinalityInfo{}}
+			for k, per := range tt.evaluated {
+				cMap.Set(k, labelInfo{cardinalityPer: per})
+			}
+
+			got := candidateCombinations(cMap, tt.labels, tt.k)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("candidateCombinations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	got := combinations([]string{"a", "b", "c", "d"}, 3)
+	want := [][]string{{"a", "b", "c"}, {"a", "b", "d"}, {"a", "c", "d"}, {"b", "c", "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("combinations() = %q, want %q", got, want)
+	}
+
+	if got := combinations([]string{"a"}, 2); len(got) != 0 {
+		t.Fatalf("combinations() of more labels than given = %q, want none", got)
+	}
+}
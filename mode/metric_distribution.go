@@ -0,0 +1,117 @@
+package mode
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// share of series for which no. of top values covering them is reported
+var coverageShares = []float64{50, 80, 95}
+
+// max rows of the cumulative share curve presented in table and csv format
+const maxCurveRows = 50
+
+type curvePoint struct {
+	Rank            int     `json:"rank"`
+	Value           string  `json:"value"`
+	Series          uint64  `json:"series"`
+	Share           float64 `json:"share"`
+	CumulativeShare float64 `json:"cumulativeShare"`
+}
+
+type coverage struct {
+	Share float64 `json:"share"`
+	// TopK is -1 if fetched values don't cover the share
+	TopK int `json:"topK"`
+}
+
+type distributionReport struct {
+	Metric        string       `json:"metric"`
+	Label         string       `json:"label"`
+	TotalSeries   uint64       `json:"totalSeries"`
+	UniqueValues  uint64       `json:"uniqueValues"`
+	FetchedValues int          `json:"fetchedValues"`
+	Coverage      []coverage   `json:"coverage"`
+	TailValues    uint64       `json:"tailValues"`
+	TailSeries    uint64       `json:"tailSeries"`
+	Curve         []curvePoint `json:"curve"`
+}
+
+func buildDistributionReport(metric, label string, totalSeries, uniqueValues uint64, values []v1.Stat) distributionReport {
+	rep := distributionReport{Metric: metric, Label: label, TotalSeries: totalSeries, UniqueValues: uniqueValues, FetchedValues: len(values)}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Value > values[j].Value
+	})
+
+	cumulative := uint64(0)
+	for i := range values {
+		cumulative += values[i].Value
+		rep.Curve = append(rep.Curve, curvePoint{
+			Rank:            i + 1,
+			Value:           values[i].Name,
+			Series:          values[i].Value,
+			Share:           round2(float64(values[i].Value) * 100 / float64(totalSeries)),
+			CumulativeShare: round2(float64(cumulative) * 100 / float64(totalSeries)),
+		})
+	}
+
+	for _, share := range coverageShares {
+		c := coverage{Share: share, TopK: -1}
+		for i := range rep.Curve {
+			if rep.Curve[i].CumulativeShare >= share {
+				c.TopK = rep.Curve[i].Rank
+				break
+			}
+		}
+		rep.Coverage = append(rep.Coverage, c)
+	}
+
+	// tail is everything beyond the values covering the largest share
+	head := rep.Coverage[len(rep.Coverage)-1].TopK
+	if head == -1 {
+		head = len(rep.Curve)
+	}
+
+	headSeries := uint64(0)
+	for i := 0; i < head; i++ {
+		headSeries += rep.Curve[i].Series
+	}
+
+	if uniqueValues > uint64(head) {
+		rep.TailValues = uniqueValues - uint64(head)
+	}
+
+	if totalSeries > headSeries {
+		rep.TailSeries = totalSeries - headSeries
+	}
+
+	return rep
+}
+
+func labelValueDistribution(v1api v1.API, m MetricFlag) {
+	r, err := apiclient.MetricInfo(v1api, m.Metric, m.Distribution, m.DistributionTopN, m.Cardinality)
+	if err != nil {
+		fmt.Println("Error while fetching label value distribution: ", err)
+		return
+	}
+
+	if len(r.SeriesCountByMetricName) == 0 {
+		fmt.Println("No series found")
+		return
+	}
+
+	uniqueValues := uint64(len(r.SeriesCountByFocusLabelValue))
+	for i := range r.LabelValueCountByLabelName {
+		if r.LabelValueCountByLabelName[i].Name == m.Distribution {
+			uniqueValues = r.LabelValueCountByLabelName[i].Value
+		}
+	}
+
+	rep := buildDistributionReport(m.Metric, m.Distribution, r.SeriesCountByMetricName[0].Value, uniqueValues, r.SeriesCountByFocusLabelValue)
+	dumpDistributionView(rep, m.DumpAs)
+}
@@ -2,8 +2,10 @@ package mode
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
 	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
@@ -26,6 +28,14 @@ type MetricFlag struct {
 	SparseDuration   int
 	ActiveTimeSeries int
 	LabelCount       string
+	Entropy          bool
+	Distribution     string
+	DistributionTopN string
+	Days             int
+	Limits           Limits
+	Histogram        int
+	HistogramLayout  int
+	VersionLabel     string
 }
 
 type metricInfo struct {
@@ -41,13 +51,17 @@ type metricInfo struct {
 	resetTime        int
 	activeTimeSeries uint64
 	isSparse         bool
+	labelStats       map[string]distributionStats
+	metricType       metricType
 }
 
 func MInfoInvoke(dataSource string, m MetricFlag) {
 	var (
 		wg    = &sync.WaitGroup{}
 		lock  = sync.RWMutex{}
-		mInfo = metricInfo{labelInfo: labelMap{}, labelValues: map[string][]map[string]uint64{}}
+		mInfo = metricInfo{labelInfo: labelMap{}, labelValues: map[string][]map[string]uint64{}, labelStats: map[string]distributionStats{}}
+		// cardinality contribution of each label, found along with its stats
+		contributions = map[string]int{}
 	)
 
 	client, err := api.NewClient(api.Config{
@@ -60,6 +74,13 @@ func MInfoInvoke(dataSource string, m MetricFlag) {
 
 	v1api := v1.NewAPI(client)
 
+	// last loss and resets depend on type of the metric, detecting it takes
+	// several calls so it is only done when they are asked for
+	typeDependent := m.Loss != 0 || m.ResetTime != 0
+	if typeDependent {
+		mInfo.metricType = detectMetricType(v1api, m.Metric, m.Lag)
+	}
+
 	// if cardinality information is asked then get cardinality with
 	// label information
 	if m.Cardinality != "" {
@@ -99,9 +120,79 @@ func MInfoInvoke(dataSource string, m MetricFlag) {
 				lock.Unlock()
 			}()
 
+			if m.Entropy && label != "__name__" {
+				uniqueCount := int(r.LabelValueCountByLabelName[l].Value)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					// series api fallback looks at the whole day asked for
+					start, err := time.Parse("2006-01-02", m.Cardinality)
+					if err != nil {
+						fmt.Println("Error while parsing cardinality date: ", err)
+						return
+					}
+					end := start.Add(24 * time.Hour)
+					if end.After(time.Now()) {
+						end = time.Now()
+					}
+
+					counts, err := labelDistribution(v1api, m.Metric, label, uniqueCount, m.Cardinality, start, end)
+					if err != nil {
+						fmt.Println("Error while fetching label value distribution: ", err)
+						return
+					}
+
+					// drop score weighs the label by series dropping it would save
+					per := 0
+					c, err := apiclient.GetQueryResult(v1api, m.Metric, int(end.Sub(start).Seconds()), int(time.Since(end).Seconds()), label, apiclient.LabelCardinalityStr)
+					if err != nil {
+						fmt.Println("Error while finding cardinality contribution: ", err)
+					} else if mInfo.cardinality > c {
+						per = int((mInfo.cardinality - c) * 100 / mInfo.cardinality)
+					}
+
+					lock.Lock()
+					mInfo.labelStats[label] = distributionOf(counts)
+					contributions[label] = per
+					lock.Unlock()
+				}()
+			}
 		}
 	}
 
+	if m.Days > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metricTrend(v1api, m)
+		}()
+	}
+
+	if m.Histogram != 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metricHistogram(v1api, m)
+		}()
+	}
+
+	if m.HistogramLayout != 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metricHistogramLayout(v1api, m)
+		}()
+	}
+
+	if m.Distribution != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			labelValueDistribution(v1api, m)
+		}()
+	}
+
 	if m.ScrapeInterval {
 		wg.Add(1)
 		go func() {
@@ -169,12 +260,12 @@ func MInfoInvoke(dataSource string, m MetricFlag) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			r, err := apiclient.LastLoss(v1api, m.Metric, m.Loss, m.Lag)
+			r, err := apiclient.LastLoss(v1api, m.Metric, mInfo.metricType.templateType(), m.Loss, m.Lag)
 			if err != nil {
 				fmt.Println("Error while finding last loss time: ", err)
 			} else {
 				mInfo.loss = r
-				fmt.Printf("Last Loss [%ds]: %d\n", m.Loss, mInfo.loss)
+				fmt.Printf("Last Loss [%ds] (%s): %d\n", m.Loss, mInfo.metricType, mInfo.loss)
 			}
 		}()
 	}
@@ -221,7 +312,13 @@ func MInfoInvoke(dataSource string, m MetricFlag) {
 		}()
 	}
 
-	if m.ResetTime != 0 {
+	if m.ResetTime != 0 && !mInfo.metricType.isCounter() && mInfo.metricType.kind != v1.MetricTypeUnknown {
+		fmt.Printf("Resets Count is only meaningful for counters, %s is a %s\n", m.Metric, mInfo.metricType.kind)
+	} else if m.ResetTime != 0 {
+		if mInfo.metricType.kind == v1.MetricTypeUnknown {
+			fmt.Printf("Type of %s couldn't be detected, counting resets taking it as a counter\n", m.Metric)
+		}
+
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -230,7 +327,7 @@ func MInfoInvoke(dataSource string, m MetricFlag) {
 				fmt.Println("Error while finding reset time: ", err)
 			} else {
 				mInfo.resetTime = r
-				fmt.Printf("Resets Count for last [%ds]: %d\n", m.ResetTime, mInfo.resetTime)
+				fmt.Printf("Resets Count for last [%ds] (%s): %d\n", m.ResetTime, mInfo.metricType, mInfo.resetTime)
 			}
 		}()
 	}
@@ -238,6 +335,49 @@ func MInfoInvoke(dataSource string, m MetricFlag) {
 	wg.Wait()
 
 	if m.Cardinality != "" {
-		dumpCardinalityInfoWithLabels(m.Metric, mInfo.cardinality, mInfo.labelInfo, mInfo.labelValues, m.DumpAs)
+		stats := mInfo.labelStats
+		if !m.Entropy {
+			stats = nil
+		}
+
+		for l, per := range contributions {
+			mInfo.labelInfo[l] = labelInfo{uniqueCount: mInfo.labelInfo[l].uniqueCount, cardinalityPer: per}
+		}
+		metricType := ""
+		if typeDependent {
+			metricType = mInfo.metricType.String()
+		}
+		dumpCardinalityInfoWithLabels(m.Metric, metricType, mInfo.cardinality, mInfo.labelInfo, mInfo.labelValues, stats, m.DumpAs)
+	}
+}
+
+// metricTrend collects series count of the metric for each of the last N days and
+// forecasts when it crosses its limit
+func metricTrend(v1api v1.API, m MetricFlag) {
+	var (
+		wg    = &sync.WaitGroup{}
+		dates = lastNDates(m.Days)
+		row   = trendRow{name: m.Metric, counts: make([]float64, len(dates)), limit: m.Limits.metricLimit(m.Metric)}
+	)
+
+	for i := range dates {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			row.counts[i] = math.NaN()
+			r, err := apiclient.MetricInfo(v1api, m.Metric, focusLabel, topN, dates[i])
+			if err != nil {
+				fmt.Println("Error while fetching cardinality info for", dates[i], ":", err)
+				return
+			}
+
+			if len(r.SeriesCountByMetricName) != 0 {
+				row.counts[i] = float64(r.SeriesCountByMetricName[0].Value)
+			}
+		}(i)
 	}
+	wg.Wait()
+
+	row.model = fitGrowth(row.counts)
+	dumpTrendView(dates, []trendRow{row}, m.DumpAs)
 }
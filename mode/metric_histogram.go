@@ -0,0 +1,266 @@
+package mode
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	bucketSuffix = "_bucket"
+	// adjacent buckets are merged until they hold atleast this share of observations
+	minBucketShare = 0.01
+	// VictoriaMetrics histograms have 18 vmrange buckets per decade
+	vmBucketsPerDecade = 18
+)
+
+const (
+	bucketNeverHit  = "never hit"
+	bucketAlwaysHit = "always hit"
+	bucketEmpty     = "empty"
+)
+
+// histogramBucket is a classic histogram bucket along with observations
+// falling in it over the window
+type histogramBucket struct {
+	le    string
+	bound float64
+	// observations <= bound
+	cumulative float64
+	// observations in (previous bound, bound]
+	observations float64
+	share        float64
+	verdict      string
+	keep         bool
+}
+
+type histogramSeries struct {
+	layout  string
+	buckets int
+	series  uint64
+	saving  float64
+}
+
+// histogramNames returns name of the bucket metric and the histogram base name
+func histogramNames(metric string) (string, string) {
+	base := strings.TrimSuffix(metric, bucketSuffix)
+	return base + bucketSuffix, base
+}
+
+// parseBuckets parses le values and returns them sorted by bound, values
+// differing only in format like 1 and 1.0 are kept once
+func parseBuckets(values []string) []*histogramBucket {
+	buckets := []*histogramBucket{}
+	seen := map[float64]bool{}
+	for _, v := range values {
+		bound, err := strconv.ParseFloat(v, 64)
+		if err != nil || seen[bound] {
+			continue
+		}
+		seen[bound] = true
+		buckets = append(buckets, &histogramBucket{le: v, bound: bound})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].bound < buckets[j].bound })
+	return buckets
+}
+
+// classifyBuckets finds observations of each bucket and marks buckets which
+// never see an observation or see every observation
+func classifyBuckets(buckets []*histogramBucket) float64 {
+	total := 0.0
+	if len(buckets) != 0 {
+		total = buckets[len(buckets)-1].cumulative
+	}
+
+	prev := 0.0
+	for i, b := range buckets {
+		b.observations = math.Max(b.cumulative-prev, 0)
+		prev = b.cumulative
+		if total != 0 {
+			b.share = round2(b.observations * 100 / total)
+		}
+
+		switch {
+		case total == 0 || b.cumulative == 0:
+			b.verdict = bucketNeverHit
+		case b.cumulative >= total && i != len(buckets)-1:
+			b.verdict = bucketAlwaysHit
+		case b.observations == 0:
+			b.verdict = bucketEmpty
+		}
+	}
+
+	return total
+}
+
+// observedRange returns index of the highest never hit bucket, -1 if none, and
+// of the lowest always hit bucket, +Inf if none, observations lie between them
+func observedRange(buckets []*histogramBucket) (int, int) {
+	lower, upper := -1, len(buckets)-1
+	for i, b := range buckets {
+		if b.verdict == bucketNeverHit {
+			lower = i
+		}
+	}
+
+	for i, b := range buckets {
+		if b.verdict == bucketAlwaysHit {
+			upper = i
+			break
+		}
+	}
+
+	return lower, upper
+}
+
+// reduceBuckets keeps the bounds of observed values and merges adjacent buckets in
+// between until they hold minBucketShare of observations, +Inf is always kept
+func reduceBuckets(buckets []*histogramBucket, total float64) {
+	if len(buckets) == 0 {
+		return
+	}
+	buckets[len(buckets)-1].keep = true
+	if total == 0 {
+		return
+	}
+
+	lower, upper := observedRange(buckets)
+	if lower >= 0 {
+		buckets[lower].keep = true
+	}
+	buckets[upper].keep = true
+
+	acc := 0.0
+	for i := lower + 1; i < upper; i++ {
+		acc += buckets[i].observations
+		if acc/total >= minBucketShare {
+			buckets[i].keep = true
+			acc = 0
+		}
+	}
+}
+
+// vmHistogramBuckets estimates vmrange buckets needed to cover observed values,
+// which is an upper bound as only buckets with observations are stored
+func vmHistogramBuckets(buckets []*histogramBucket) int {
+	lower, upper := observedRange(buckets)
+	// observations above the highest finite bound are taken to be within it
+	if math.IsInf(buckets[upper].bound, 1) && upper > 0 {
+		upper--
+	}
+
+	// vmrange buckets are logarithmic, the lowest positive bound is taken for 0
+	for lower < upper && (lower < 0 || buckets[lower].bound <= 0) {
+		lower++
+	}
+
+	if lower < 0 || lower >= upper {
+		return 1
+	}
+
+	return int(math.Ceil(vmBucketsPerDecade*math.Log10(buckets[upper].bound/buckets[lower].bound))) + 1
+}
+
+func histogramSeriesEstimate(buckets []*histogramBucket, labelSets uint64) []histogramSeries {
+	kept := 0
+	for _, b := range buckets {
+		if b.keep {
+			kept++
+		}
+	}
+
+	vmBuckets := vmHistogramBuckets(buckets)
+
+	// a classic histogram has a series per bucket along with _sum and _count
+	classic := labelSets * uint64(len(buckets)+2)
+	estimates := []histogramSeries{
+		{layout: "Classic (current)", buckets: len(buckets), series: classic},
+		{layout: "Classic (reduced)", buckets: kept, series: labelSets * uint64(kept+2)},
+		{layout: "Native", buckets: 1, series: labelSets},
+		{layout: "VictoriaMetrics (upper bound)", buckets: vmBuckets, series: labelSets * uint64(vmBuckets+2)},
+	}
+
+	for i := range estimates {
+		if classic != 0 {
+			estimates[i].saving = round2(100 - float64(estimates[i].series)*100/float64(classic))
+		}
+	}
+
+	return estimates
+}
+
+// metricHistogram analyses buckets of a classic histogram over the window and
+// recommends a reduced bucket set
+func metricHistogram(v1api v1.API, m MetricFlag) {
+	bucketMetric, base := histogramNames(m.Metric)
+
+	end := time.Now().Add(-time.Duration(m.Lag) * time.Second)
+	values, err := apiclient.LabelValues(v1api, "le", []string{bucketMetric}, end.Add(-time.Duration(m.Histogram)*time.Second), end)
+	if err != nil {
+		fmt.Println("Error while finding buckets: ", err)
+		return
+	}
+
+	leValues := make([]string, len(values))
+	for i := range values {
+		leValues[i] = string(values[i])
+	}
+
+	buckets := parseBuckets(leValues)
+	if len(buckets) == 0 {
+		fmt.Println("No buckets found for", bucketMetric)
+		return
+	}
+
+	r, err := apiclient.QueryVector(v1api, fmt.Sprintf("sum by (le) (increase(%s[%ds]))", bucketMetric, m.Histogram), m.Lag)
+	if err != nil {
+		fmt.Println("Error while finding observations per bucket: ", err)
+		return
+	}
+
+	byLe := map[float64]float64{}
+	for _, s := range r {
+		bound, err := strconv.ParseFloat(string(s.Metric["le"]), 64)
+		if err == nil {
+			byLe[bound] += float64(s.Value)
+		}
+	}
+	for _, b := range buckets {
+		b.cumulative = byLe[b.bound]
+	}
+
+	total := classifyBuckets(buckets)
+	reduceBuckets(buckets, total)
+
+	labelSets := uint64(0)
+	c, err := apiclient.QueryVector(v1api, fmt.Sprintf("count(count without (le) (%s))", bucketMetric), m.Lag)
+	if err != nil {
+		fmt.Println("Error while finding series of histogram: ", err)
+	} else if len(c) != 0 {
+		labelSets = uint64(c[0].Value)
+	}
+
+	dumpHistogramBucketsView(base, total, buckets, m.DumpAs)
+
+	if total == 0 {
+		fmt.Printf("No observations in the last %ds, increase --histogram to find a reduced bucket set\n\n", m.Histogram)
+	} else {
+		kept := []string{}
+		for _, b := range buckets {
+			if b.keep {
+				kept = append(kept, b.le)
+			}
+		}
+		fmt.Printf("Recommended buckets: %s\n\n", strings.Join(kept, ", "))
+	}
+
+	dumpHistogramSeriesView(labelSets, histogramSeriesEstimate(buckets, labelSets), m.DumpAs)
+}
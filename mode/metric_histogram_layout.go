@@ -0,0 +1,247 @@
+package mode
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// bucketLayout is a set of le values along with instances exposing exactly that set
+type bucketLayout struct {
+	les       []string
+	instances []string
+	missing   []string
+	extra     []string
+	noInf     bool
+	reference bool
+}
+
+// layoutChange is an instance which stopped exposing some buckets within the window
+type layoutChange struct {
+	instance string
+	retired  []string
+}
+
+// nonMonotonicSeries is a label set whose cumulative bucket counts decrease at le
+type nonMonotonicSeries struct {
+	instance string
+	series   string
+	le       string
+}
+
+// histogramInstance identifies an instance by job, instance and version labels
+func histogramInstance(ls model.LabelSet, versionLabel string) string {
+	parts := []string{}
+	for _, l := range []string{"job", "instance", versionLabel} {
+		if v, ok := ls[model.LabelName(l)]; ok {
+			parts = append(parts, l+"="+string(v))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func sortLes(les map[string]bool) []string {
+	values := make([]string, 0, len(les))
+	for le := range les {
+		values = append(values, le)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		a, _ := strconv.ParseFloat(values[i], 64)
+		b, _ := strconv.ParseFloat(values[j], 64)
+		return a < b
+	})
+	return values
+}
+
+// instanceLes returns le values exposed by each instance
+func instanceLes(series []model.LabelSet, versionLabel string) map[string]map[string]bool {
+	byInstance := map[string]map[string]bool{}
+	for _, ls := range series {
+		le, ok := ls["le"]
+		if !ok {
+			continue
+		}
+
+		inst := histogramInstance(ls, versionLabel)
+		if byInstance[inst] == nil {
+			byInstance[inst] = map[string]bool{}
+		}
+		byInstance[inst][string(le)] = true
+	}
+
+	return byInstance
+}
+
+// layoutChanges compares le values seen over the window with the latest ones of
+// each instance, instances which stopped exposing buckets are reported
+func layoutChanges(window, latest map[string]map[string]bool) []layoutChange {
+	changes := []layoutChange{}
+	for inst, les := range latest {
+		retired := map[string]bool{}
+		for le := range window[inst] {
+			if !les[le] {
+				retired[le] = true
+			}
+		}
+
+		if len(retired) != 0 {
+			changes = append(changes, layoutChange{instance: inst, retired: sortLes(retired)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].instance < changes[j].instance })
+	return changes
+}
+
+// bucketLayouts groups instances by the le values they expose, the layout
+// exposed by most instances is the reference others are compared against
+func bucketLayouts(byInstance map[string]map[string]bool) []*bucketLayout {
+	layouts := []*bucketLayout{}
+	byKey := map[string]*bucketLayout{}
+	instances := make([]string, 0, len(byInstance))
+	for inst := range byInstance {
+		instances = append(instances, inst)
+	}
+	sort.Strings(instances)
+
+	for _, inst := range instances {
+		les := sortLes(byInstance[inst])
+		key := strings.Join(les, ",")
+		l, ok := byKey[key]
+		if !ok {
+			l = &bucketLayout{les: les, noInf: !byInstance[inst]["+Inf"]}
+			byKey[key] = l
+			layouts = append(layouts, l)
+		}
+		l.instances = append(l.instances, inst)
+	}
+
+	sort.SliceStable(layouts, func(i, j int) bool { return len(layouts[i].instances) > len(layouts[j].instances) })
+	if len(layouts) == 0 {
+		return layouts
+	}
+
+	layouts[0].reference = true
+	ref := map[string]bool{}
+	for _, le := range layouts[0].les {
+		ref[le] = true
+	}
+
+	for _, l := range layouts[1:] {
+		own := map[string]bool{}
+		for _, le := range l.les {
+			own[le] = true
+			if !ref[le] {
+				l.extra = append(l.extra, le)
+			}
+		}
+
+		for _, le := range layouts[0].les {
+			if !own[le] {
+				l.missing = append(l.missing, le)
+			}
+		}
+	}
+
+	return layouts
+}
+
+// nonMonotonicBuckets finds label sets whose cumulative count decreases as le increases
+func nonMonotonicBuckets(vector model.Vector, versionLabel string) []nonMonotonicSeries {
+	type bucket struct {
+		le    string
+		bound float64
+		value float64
+	}
+
+	bySeries := map[string][]bucket{}
+	instances := map[string]string{}
+	for _, s := range vector {
+		le, ok := s.Metric["le"]
+		if !ok {
+			continue
+		}
+
+		bound, err := strconv.ParseFloat(string(le), 64)
+		if err != nil {
+			continue
+		}
+
+		ls := model.LabelSet(s.Metric).Clone()
+		delete(ls, "le")
+		delete(ls, model.MetricNameLabel)
+		key := ls.String()
+		bySeries[key] = append(bySeries[key], bucket{le: string(le), bound: bound, value: float64(s.Value)})
+		instances[key] = histogramInstance(ls, versionLabel)
+	}
+
+	result := []nonMonotonicSeries{}
+	for key, buckets := range bySeries {
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i].bound < buckets[j].bound })
+		prev := math.Inf(-1)
+		for _, b := range buckets {
+			if b.value < prev {
+				result = append(result, nonMonotonicSeries{instance: instances[key], series: key, le: b.le})
+				break
+			}
+			prev = b.value
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].series < result[j].series })
+	return result
+}
+
+// metricHistogramLayout checks that every instance of a histogram exposes the
+// same buckets at the latest sample and that bucket counts are cumulative, buckets
+// an instance exposed within the window but not any more are reported as changes
+func metricHistogramLayout(v1api v1.API, m MetricFlag) {
+	bucketMetric, base := histogramNames(m.Metric)
+
+	vector, err := apiclient.QueryVector(v1api, bucketMetric, m.Lag)
+	if err != nil {
+		fmt.Println("Error while finding bucket counts: ", err)
+		return
+	}
+
+	current := make([]model.LabelSet, len(vector))
+	for i := range vector {
+		current[i] = model.LabelSet(vector[i].Metric)
+	}
+
+	latest := instanceLes(current, m.VersionLabel)
+	layouts := bucketLayouts(latest)
+	if len(layouts) == 0 {
+		fmt.Println("No buckets found for", bucketMetric)
+		return
+	}
+
+	dumpBucketLayoutsView(base, layouts, m.DumpAs)
+
+	end := time.Now().Add(-time.Duration(m.Lag) * time.Second)
+	series, err := apiclient.Series(v1api, []string{bucketMetric}, end.Add(-time.Duration(m.HistogramLayout)*time.Second), end)
+	if err != nil {
+		fmt.Println("Error while fetching series of histogram: ", err)
+	} else if changes := layoutChanges(instanceLes(series, m.VersionLabel), latest); len(changes) != 0 {
+		dumpLayoutChangesView(changes, m.DumpAs)
+	}
+
+	nonMonotonic := nonMonotonicBuckets(vector, m.VersionLabel)
+	if len(nonMonotonic) == 0 {
+		fmt.Println("Bucket counts of every series are cumulative")
+		return
+	}
+
+	dumpNonMonotonicBucketsView(nonMonotonic, m.DumpAs)
+}
@@ -0,0 +1,290 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+type dropPlan struct {
+	labels          []string
+	series          uint64
+	duplicateExists bool
+}
+
+// planCache keeps the series count of every label set evaluated so far, so
+// greedy and exhaustive search never query the same drop plan twice
+type planCache struct {
+	sync.RWMutex
+	m map[string]uint64
+}
+
+func (p *planCache) Get(key string) (uint64, bool) {
+	p.RLock()
+	defer p.RUnlock()
+	v, ok := p.m[key]
+	return v, ok
+}
+
+func (p *planCache) Set(key string, val uint64) {
+	p.Lock()
+	defer p.Unlock()
+	p.m[key] = val
+}
+
+func planKey(labels []string) string {
+	return strings.Join(labels, ", ")
+}
+
+// isSuperset reports if all labels of sub are present in labels
+func isSuperset(labels, sub []string) bool {
+	set := map[string]bool{}
+	for _, l := range labels {
+		set[l] = true
+	}
+
+	for _, l := range sub {
+		if !set[l] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// evaluatePlan returns series left after dropping the labels, failed evaluations
+// aren't cached so that they are never taken as reaching the target. An empty
+// result is a failure too, the metric has series so some must be left.
+func evaluatePlan(v1api v1.API, cFlag CardinalityFlag, cache *planCache, labels []string) (uint64, error) {
+	key := planKey(labels)
+	if v, ok := cache.Get(key); ok {
+		return v, nil
+	}
+
+	r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, key, apiclient.LabelCardinalityStr)
+	if err != nil {
+		fmt.Println("Error while finding cardinality:", err)
+		return 0, err
+	}
+
+	if r == 0 {
+		return 0, fmt.Errorf("no series found after dropping %s", key)
+	}
+
+	cache.Set(key, r)
+	return r, nil
+}
+
+// greedyPlan keeps dropping the label which brings the cardinality down the most
+// until the target is reached or no labels are left
+func greedyPlan(v1api v1.API, cFlag CardinalityFlag, cache *planCache, labels []string) []string {
+	current := []string{}
+	remaining := append([]string{}, labels...)
+
+	for len(remaining) != 0 {
+		var (
+			wg     = &sync.WaitGroup{}
+			series = make([]uint64, len(remaining))
+			failed = make([]bool, len(remaining))
+		)
+
+		for i := range remaining {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				var err error
+				series[i], err = evaluatePlan(v1api, cFlag, cache, append(append([]string{}, current...), remaining[i]))
+				failed[i] = err != nil
+			}(i)
+		}
+		wg.Wait()
+
+		best := -1
+		for i := range series {
+			if !failed[i] && (best == -1 || series[i] < series[best]) {
+				best = i
+			}
+		}
+
+		if best == -1 {
+			return nil
+		}
+
+		current = append(current, remaining[best])
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		if series[best] <= uint64(cFlag.Target) {
+			return current
+		}
+	}
+
+	return nil
+}
+
+// exhaustivePlans evaluates every label subset in increasing order of size, a subset
+// is skipped if one of its subsets already reaches the target as it can't be minimal
+func exhaustivePlans(v1api v1.API, cFlag CardinalityFlag, cache *planCache, labels []string) [][]string {
+	found := [][]string{}
+	for k := 1; k <= len(labels); k++ {
+		var (
+			wg      = &sync.WaitGroup{}
+			lock    = sync.Mutex{}
+			toCheck = [][]string{}
+		)
+
+		for _, comb := range combinations(labels, k) {
+			minimal := true
+			for _, f := range found {
+				if isSuperset(comb, f) {
+					minimal = false
+					break
+				}
+			}
+
+			if minimal {
+				toCheck = append(toCheck, comb)
+			}
+		}
+
+		for i := range toCheck {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				series, err := evaluatePlan(v1api, cFlag, cache, toCheck[i])
+				if err == nil && series <= uint64(cFlag.Target) {
+					lock.Lock()
+					found = append(found, toCheck[i])
+					lock.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	return found
+}
+
+func OptimizeInvoke(dataSource string, cFlag CardinalityFlag) {
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	r, err := apiclient.MetricInfo(v1api, cFlag.Metric, focusLabel, topN, "")
+	if err != nil {
+		fmt.Println("Error while fetching cardinality info: ", err)
+		return
+	}
+
+	if len(r.SeriesCountByMetricName) == 0 {
+		fmt.Println("No series found")
+		return
+	}
+
+	cardinality := r.SeriesCountByMetricName[0].Value
+	if cardinality <= uint64(cFlag.Target) {
+		fmt.Printf("Cardinality %d is already within the target %d, nothing to drop\n", cardinality, cFlag.Target)
+		return
+	}
+
+	labels := []string{}
+	for l := range r.LabelValueCountByLabelName {
+		if r.LabelValueCountByLabelName[l].Name == "__name__" {
+			continue
+		}
+		labels = append(labels, r.LabelValueCountByLabelName[l].Name)
+	}
+
+	if len(cFlag.Label) != 0 {
+		labels = cFlag.Label
+	}
+
+	cache := &planCache{m: map[string]uint64{}}
+	candidates := [][]string{}
+	if g := greedyPlan(v1api, cFlag, cache, labels); g != nil {
+		candidates = append(candidates, g)
+	}
+
+	if len(labels) <= cFlag.ExhaustiveLimit {
+		candidates = append(candidates, exhaustivePlans(v1api, cFlag, cache, labels)...)
+	}
+
+	// keep only minimal and unique plans, greedy plan may be a superset of
+	// a plan found by exhaustive search
+	plans := []dropPlan{}
+	for i := range candidates {
+		minimal := true
+		for j := range candidates {
+			if i == j || len(candidates[j]) > len(candidates[i]) {
+				continue
+			}
+
+			if isSuperset(candidates[i], candidates[j]) && (len(candidates[j]) < len(candidates[i]) || j < i) {
+				minimal = false
+				break
+			}
+		}
+
+		if minimal {
+			series, _ := cache.Get(planKey(candidates[i]))
+			plans = append(plans, dropPlan{labels: candidates[i], series: series})
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := range plans {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, planKey(plans[i].labels), apiclient.DuplicatesLabelsStr)
+			if err != nil {
+				fmt.Println("Error while finding duplicate labels exists:", err)
+			}
+
+			plans[i].duplicateExists = r == 1
+		}(i)
+	}
+	wg.Wait()
+
+	if !cFlag.AllowDuplicates {
+		filtered := []dropPlan{}
+		for i := range plans {
+			if !plans[i].duplicateExists {
+				filtered = append(filtered, plans[i])
+			}
+		}
+		plans = filtered
+	}
+
+	if len(plans) == 0 {
+		fmt.Println("No drop plan reaches the target without creating duplicates, set --allow-duplicates to list plans which need aggregation")
+		return
+	}
+
+	// fewer labels dropped is better, among equals prefer the plan that keeps
+	// the most series as it loses the least information
+	sort.Slice(plans, func(i, j int) bool {
+		if len(plans[i].labels) != len(plans[j].labels) {
+			return len(plans[i].labels) < len(plans[j].labels)
+		}
+
+		return plans[i].series > plans[j].series
+	})
+
+	if cFlag.MaxPlans > 0 && len(plans) > cFlag.MaxPlans {
+		plans = plans[:cFlag.MaxPlans]
+	}
+
+	dumpDropPlans(cFlag.Metric, cardinality, cFlag.Target, plans, cFlag.DumpAs)
+}
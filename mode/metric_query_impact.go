@@ -0,0 +1,112 @@
+package mode
+
+import (
+	"sort"
+	"strings"
+)
+
+// queries not using the dropped labels presented per drop candidate, the rest are counted
+const unaffectedQueriesPerDrop = 3
+
+// queryImpact is how a top query referencing the metric is affected by a drop,
+// a row with omitted set stands for that many unaffected queries left out
+type queryImpact struct {
+	labels      string
+	query       string
+	usesLabel   bool
+	count       float64
+	avgDuration float64
+	// expected speed up, assuming query time grows with series of the metric
+	speedUp float64
+	after   float64
+	omitted int
+}
+
+// queryImpacts finds top queries which reference the metric for every drop candidate,
+// along with whether they use the dropped labels and expected speed up from the series
+// reduction. Speed up is an upper bound as queries may touch other metrics as well.
+// Queries using the dropped labels break, so all of them are listed first, only the
+// heaviest few of the rest are listed.
+func queryImpacts(queries []*topQuery, metric string, cMap labelsCardinalityInfo) []queryImpact {
+	type referencing struct {
+		q    *topQuery
+		used map[string]bool
+		// query couldn't be parsed, it is matched by words instead
+		unparsed bool
+	}
+
+	refs := []referencing{}
+	for _, q := range queries {
+		expr, err := parseQuery(q.query)
+		if err != nil {
+			// a query mentioning the metric is taken to reference it and
+			// to use every label it mentions
+			if containsWord(q.query, metric) {
+				refs = append(refs, referencing{q: q, unparsed: true})
+			}
+			continue
+		}
+
+		if ok, used := queryLabelUsage(expr, metric); ok {
+			refs = append(refs, referencing{q: q, used: used})
+		}
+	}
+
+	// heaviest queries first
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].q.sumDuration > refs[j].q.sumDuration
+	})
+
+	keys := make([]string, 0, len(cMap))
+	for k := range cMap {
+		keys = append(keys, k)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if cMap[keys[i]].cardinalityPer != cMap[keys[j]].cardinalityPer {
+			return cMap[keys[i]].cardinalityPer > cMap[keys[j]].cardinalityPer
+		}
+		return keys[i] < keys[j]
+	})
+
+	impacts := []queryImpact{}
+	for _, k := range keys {
+		// series left after drop, in % of current
+		remaining := float64(100 - cMap[k].cardinalityPer)
+		if remaining < 1 {
+			remaining = 1
+		}
+		speedUp := round2(100 / remaining)
+
+		affected, unaffected := []queryImpact{}, []queryImpact{}
+		for _, r := range refs {
+			uses := false
+			for _, l := range strings.Split(k, ", ") {
+				uses = uses || r.used[l] || (r.unparsed && containsWord(r.q.query, l))
+			}
+
+			impact := queryImpact{
+				labels:      k,
+				query:       r.q.query,
+				usesLabel:   uses,
+				count:       r.q.count,
+				avgDuration: r.q.avgDuration,
+				speedUp:     speedUp,
+				after:       round2(r.q.avgDuration / speedUp),
+			}
+			if uses {
+				affected = append(affected, impact)
+			} else {
+				unaffected = append(unaffected, impact)
+			}
+		}
+
+		impacts = append(impacts, affected...)
+		if len(unaffected) > unaffectedQueriesPerDrop {
+			omitted := len(unaffected) - unaffectedQueriesPerDrop
+			unaffected = append(unaffected[:unaffectedQueriesPerDrop], queryImpact{labels: k, omitted: omitted})
+		}
+		impacts = append(impacts, unaffected...)
+	}
+
+	return impacts
+}
@@ -0,0 +1,149 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	oneToOne   = "1:1"
+	oneToMany  = "1:N"
+	manyToOne  = "N:1"
+	manyToMany = "M:N"
+)
+
+type labelRelation struct {
+	labelA   string
+	labelB   string
+	uniqueA  uint64
+	uniqueB  uint64
+	uniqueAB uint64
+	relation string
+}
+
+// classifyRelation finds how values of label A map to values of label B
+// using distinct counts of A, B and (A, B)
+func classifyRelation(uniqueA, uniqueB, uniqueAB uint64) string {
+	switch {
+	case uniqueAB == uniqueA && uniqueAB == uniqueB:
+		// every value of A pairs with exactly one value of B and vice versa
+		return oneToOne
+	case uniqueAB == uniqueA:
+		// every value of A pairs with one value of B, B is determined by A
+		return manyToOne
+	case uniqueAB == uniqueB:
+		// every value of B pairs with one value of A, A is determined by B
+		return oneToMany
+	default:
+		return manyToMany
+	}
+}
+
+// redundancyNote explains which label of the pair can be dropped without any
+// change in cardinality, empty if none
+func redundancyNote(r labelRelation) string {
+	switch r.relation {
+	case oneToOne:
+		return fmt.Sprintf("drop either %s or %s", r.labelA, r.labelB)
+	case manyToOne:
+		return fmt.Sprintf("drop %s while %s is kept", r.labelB, r.labelA)
+	case oneToMany:
+		return fmt.Sprintf("drop %s while %s is kept", r.labelA, r.labelB)
+	}
+
+	return ""
+}
+
+func RelationsInvoke(dataSource string, cFlag CardinalityFlag) {
+	var (
+		wg     = &sync.WaitGroup{}
+		lock   = sync.RWMutex{}
+		counts = map[string]uint64{}
+	)
+
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	r, err := apiclient.MetricInfo(v1api, cFlag.Metric, focusLabel, topN, "")
+	if err != nil {
+		fmt.Println("Error while fetching cardinality info: ", err)
+		return
+	}
+
+	if len(r.SeriesCountByMetricName) == 0 {
+		fmt.Println("No series found")
+		return
+	}
+
+	labels := []string{}
+	for l := range r.LabelValueCountByLabelName {
+		if r.LabelValueCountByLabelName[l].Name == "__name__" {
+			continue
+		}
+		labels = append(labels, r.LabelValueCountByLabelName[l].Name)
+	}
+
+	if len(cFlag.Label) != 0 {
+		labels = cFlag.Label
+	}
+
+	if len(labels) < 2 {
+		fmt.Println("Atleast 2 labels are needed to find relations")
+		return
+	}
+
+	groups := combinations(labels, 1)
+	groups = append(groups, combinations(labels, 2)...)
+	for _, g := range groups {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			r, err := apiclient.GetQueryResult(v1api, cFlag.Metric, cFlag.CardinalityPerDuration, cFlag.Lag, key, apiclient.LabelGroupCountStr)
+			if err != nil {
+				fmt.Println("Error while finding unique label values:", err)
+				return
+			}
+
+			lock.Lock()
+			counts[key] = r
+			lock.Unlock()
+		}(planKey(g))
+	}
+	wg.Wait()
+
+	relations := []labelRelation{}
+	for _, pair := range combinations(labels, 2) {
+		rel := labelRelation{
+			labelA:   pair[0],
+			labelB:   pair[1],
+			uniqueA:  counts[pair[0]],
+			uniqueB:  counts[pair[1]],
+			uniqueAB: counts[planKey(pair)],
+		}
+
+		// a failed query leaves nothing to classify
+		if rel.uniqueA == 0 || rel.uniqueB == 0 || rel.uniqueAB == 0 {
+			continue
+		}
+
+		rel.relation = classifyRelation(rel.uniqueA, rel.uniqueB, rel.uniqueAB)
+		relations = append(relations, rel)
+	}
+
+	dumpRelationMatrix(cFlag.Metric, labels, relations, cFlag.DumpAs)
+	dumpRelations(relations, cFlag.DumpAs)
+	dumpRedundantLabels(relations, cFlag.DumpAs)
+}
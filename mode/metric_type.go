@@ -0,0 +1,181 @@
+package mode
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	typeFromMetadata        = "metadata"
+	typeFromTargetsMetadata = "targets metadata"
+	typeFromName            = "naming convention"
+	typeFromSamples         = "sample behaviour"
+	// window over which sample behaviour is observed, in seconds
+	typeDetectionWindow = 3600
+	// a counter only decreases on reset, so decreases are a small share of its changes
+	counterResetShare = 0.1
+)
+
+// suffixes of series which are counters, either on their own or as part of a histogram or summary
+var counterSuffixes = []string{"_total", "_bucket", "_count", "_sum"}
+
+// metricType is the detected type of a metric along with how it was detected
+type metricType struct {
+	kind v1.MetricType
+	// type of series the metric is part of, e.g. histogram for _bucket series
+	parent v1.MetricType
+	source string
+}
+
+func (t metricType) isCounter() bool {
+	return t.kind == v1.MetricTypeCounter
+}
+
+// templateType is the metric type as expected by query templates
+func (t metricType) templateType() string {
+	if t.isCounter() {
+		return "Counter"
+	}
+	return "Gauge"
+}
+
+func (t metricType) String() string {
+	s := string(t.kind)
+	if t.parent != "" {
+		s += " (" + string(t.parent) + ")"
+	}
+	return s + ", detected from " + t.source
+}
+
+// seriesType returns type of a series of a metric of the given type, series of
+// histograms and summaries with counter suffixes are counters
+func seriesType(metric, base string, t v1.MetricType) metricType {
+	if metric == base {
+		return metricType{kind: t}
+	}
+
+	switch t {
+	case v1.MetricTypeHistogram, v1.MetricTypeGaugeHistogram, v1.MetricTypeSummary:
+		kind := v1.MetricTypeGauge
+		if t != v1.MetricTypeGaugeHistogram && hasCounterSuffix(metric) {
+			kind = v1.MetricTypeCounter
+		}
+		return metricType{kind: kind, parent: t}
+	}
+
+	return metricType{kind: t}
+}
+
+func hasCounterSuffix(metric string) bool {
+	for _, s := range counterSuffixes {
+		if strings.HasSuffix(metric, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// metadataNames returns the metric name and the names metadata of its parent
+// histogram or summary is kept under
+func metadataNames(metric string) []string {
+	names := []string{metric}
+	for _, s := range []string{"_bucket", "_count", "_sum", "_total"} {
+		if strings.HasSuffix(metric, s) {
+			names = append(names, strings.TrimSuffix(metric, s))
+		}
+	}
+	return names
+}
+
+func typeFromMetadataAPI(v1api v1.API, metric string) (metricType, bool) {
+	for _, name := range metadataNames(metric) {
+		r, err := apiclient.Metadata(v1api, name)
+		if err != nil {
+			fmt.Println("Error while fetching metadata: ", err)
+			return metricType{}, false
+		}
+
+		for _, md := range r[name] {
+			if md.Type != "" && md.Type != v1.MetricTypeUnknown {
+				t := seriesType(metric, name, md.Type)
+				t.source = typeFromMetadata
+				return t, true
+			}
+		}
+	}
+
+	return metricType{}, false
+}
+
+func typeFromTargetsMetadataAPI(v1api v1.API, metric string) (metricType, bool) {
+	for _, name := range metadataNames(metric) {
+		r, err := apiclient.TargetsMetadata(v1api, name)
+		if err != nil {
+			fmt.Println("Error while fetching targets metadata: ", err)
+			return metricType{}, false
+		}
+
+		for _, md := range r {
+			if md.Metric == name && md.Type != "" && md.Type != v1.MetricTypeUnknown {
+				t := seriesType(metric, name, md.Type)
+				t.source = typeFromTargetsMetadata
+				return t, true
+			}
+		}
+	}
+
+	return metricType{}, false
+}
+
+// typeFromSampleBehaviour takes a metric which mostly increases and only
+// occasionally decreases, i.e. resets, to be a counter
+func typeFromSampleBehaviour(v1api v1.API, metric string, offset int) (metricType, bool) {
+	resets, err := apiclient.QueryVector(v1api, fmt.Sprintf("sum(resets(%s[%ds]))", metric, typeDetectionWindow), offset)
+	if err != nil {
+		fmt.Println("Error while finding resets: ", err)
+		return metricType{}, false
+	}
+
+	changes, err := apiclient.QueryVector(v1api, fmt.Sprintf("sum(changes(%s[%ds]))", metric, typeDetectionWindow), offset)
+	if err != nil {
+		fmt.Println("Error while finding changes: ", err)
+		return metricType{}, false
+	}
+
+	if len(resets) == 0 || len(changes) == 0 || changes[0].Value == 0 {
+		return metricType{}, false
+	}
+
+	t := metricType{kind: v1.MetricTypeGauge, source: typeFromSamples}
+	if float64(resets[0].Value)/float64(changes[0].Value) < counterResetShare {
+		t.kind = v1.MetricTypeCounter
+	}
+
+	return t, true
+}
+
+// detectMetricType finds type of the metric from metadata, then metadata of
+// targets, then naming conventions and at last behaviour of its samples
+func detectMetricType(v1api v1.API, metric string, offset int) metricType {
+	if t, ok := typeFromMetadataAPI(v1api, metric); ok {
+		return t
+	}
+
+	if t, ok := typeFromTargetsMetadataAPI(v1api, metric); ok {
+		return t
+	}
+
+	if hasCounterSuffix(metric) {
+		return metricType{kind: v1.MetricTypeCounter, source: typeFromName}
+	}
+
+	if t, ok := typeFromSampleBehaviour(v1api, metric, offset); ok {
+		return t
+	}
+
+	return metricType{kind: v1.MetricTypeUnknown, source: typeFromSamples}
+}
@@ -0,0 +1,947 @@
+package mode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A small parser for PromQL and the commonly used part of MetricsQL. It is meant
+// for finding out what a query touches: selectors, their matchers and ranges and
+// labels used by aggregations and vector matching, not for evaluating queries.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokDuration
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// binary operators by precedence, higher binds tighter
+var binaryPrecedence = map[string]int{
+	"default": 0, "if": 0, "ifnot": 0,
+	"or":  1,
+	"and": 2, "unless": 2,
+	"==": 3, "!=": 3, "<=": 3, "<": 3, ">=": 3, ">": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5, "atan2": 5,
+	"^": 6,
+}
+
+var aggregateOps = map[string]bool{
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true,
+	"stdvar": true, "count": true, "count_values": true, "bottomk": true, "topk": true,
+	"quantile": true, "limitk": true, "limit_ratio": true,
+	// MetricsQL only
+	"any": true, "distinct": true, "geomean": true, "histogram": true, "mad": true,
+	"median": true, "mode": true, "outliersk": true, "share": true, "sum2": true, "zscore": true,
+	"topk_avg": true, "topk_max": true, "topk_min": true, "topk_last": true, "topk_median": true,
+	"bottomk_avg": true, "bottomk_max": true, "bottomk_min": true, "bottomk_last": true, "bottomk_median": true,
+}
+
+type exprNode interface {
+	String() string
+}
+
+type numberLiteral struct {
+	val string
+}
+
+type stringLiteral struct {
+	val string
+}
+
+type vectorSelector struct {
+	name     string
+	matchers []labelMatcher
+	// range of a range vector selector, e.g. 5m, empty for instant vector
+	rng    string
+	offset string
+	at     string
+}
+
+type parenExpr struct {
+	expr exprNode
+}
+
+type unaryExpr struct {
+	op   string
+	expr exprNode
+}
+
+type callExpr struct {
+	fn              string
+	args            []exprNode
+	keepMetricNames bool
+}
+
+type aggregateExpr struct {
+	op   string
+	args []exprNode
+	// by, without or empty
+	modifier string
+	grouping []string
+}
+
+type subqueryExpr struct {
+	expr   exprNode
+	rng    string
+	step   string
+	offset string
+	at     string
+}
+
+type binaryExpr struct {
+	op         string
+	lhs, rhs   exprNode
+	returnBool bool
+	// on, ignoring or empty
+	matching    string
+	matchLabels []string
+	// group_left, group_right or empty
+	card    string
+	include []string
+}
+
+func (n *numberLiteral) String() string { return n.val }
+
+func (n *stringLiteral) String() string { return strconv.Quote(n.val) }
+
+func (n *vectorSelector) String() string {
+	var b strings.Builder
+	b.WriteString(n.name)
+	if len(n.matchers) != 0 || n.name == "" {
+		ms := make([]string, len(n.matchers))
+		for i := range n.matchers {
+			ms[i] = n.matchers[i].String()
+		}
+		b.WriteString("{" + strings.Join(ms, ", ") + "}")
+	}
+
+	if n.rng != "" {
+		b.WriteString("[" + n.rng + "]")
+	}
+	writeOffsetAt(&b, n.offset, n.at)
+	return b.String()
+}
+
+func (n *parenExpr) String() string { return "(" + n.expr.String() + ")" }
+
+func (n *unaryExpr) String() string { return n.op + n.expr.String() }
+
+func (n *callExpr) String() string {
+	s := n.fn + "(" + joinExprs(n.args) + ")"
+	if n.keepMetricNames {
+		s += " keep_metric_names"
+	}
+	return s
+}
+
+func (n *aggregateExpr) String() string {
+	s := n.op
+	if n.modifier != "" {
+		s += " " + n.modifier + " (" + strings.Join(n.grouping, ", ") + ")"
+	}
+	return s + " (" + joinExprs(n.args) + ")"
+}
+
+func (n *subqueryExpr) String() string {
+	var b strings.Builder
+	b.WriteString(n.expr.String() + "[" + n.rng + ":" + n.step + "]")
+	writeOffsetAt(&b, n.offset, n.at)
+	return b.String()
+}
+
+func (n *binaryExpr) String() string {
+	s := n.lhs.String() + " " + n.op
+	if n.returnBool {
+		s += " bool"
+	}
+
+	if n.matching != "" {
+		s += " " + n.matching + " (" + strings.Join(n.matchLabels, ", ") + ")"
+	}
+
+	if n.card != "" {
+		s += " " + n.card + " (" + strings.Join(n.include, ", ") + ")"
+	}
+	return s + " " + n.rhs.String()
+}
+
+func writeOffsetAt(b *strings.Builder, offset, at string) {
+	if offset != "" {
+		b.WriteString(" offset " + offset)
+	}
+
+	if at != "" {
+		b.WriteString(" @ " + at)
+	}
+}
+
+func joinExprs(exprs []exprNode) string {
+	s := make([]string, len(exprs))
+	for i := range exprs {
+		s[i] = exprs[i].String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// children returns direct sub expressions of a node
+func children(node exprNode) []exprNode {
+	switch n := node.(type) {
+	case *parenExpr:
+		return []exprNode{n.expr}
+	case *unaryExpr:
+		return []exprNode{n.expr}
+	case *callExpr:
+		return n.args
+	case *aggregateExpr:
+		return n.args
+	case *subqueryExpr:
+		return []exprNode{n.expr}
+	case *binaryExpr:
+		return []exprNode{n.lhs, n.rhs}
+	}
+
+	return nil
+}
+
+// inspectExpr visits every node depth first along with its ancestors, closest last
+func inspectExpr(node exprNode, fn func(node exprNode, parents []exprNode)) {
+	var visit func(node exprNode, parents []exprNode)
+	visit = func(node exprNode, parents []exprNode) {
+		fn(node, parents)
+		parents = append(parents, node)
+		for _, c := range children(node) {
+			visit(c, parents[:len(parents):len(parents)])
+		}
+	}
+	visit(node, nil)
+}
+
+// querySelectors returns all selectors of the expression in order of appearance
+func querySelectors(node exprNode) []*vectorSelector {
+	selectors := []*vectorSelector{}
+	inspectExpr(node, func(n exprNode, _ []exprNode) {
+		if s, ok := n.(*vectorSelector); ok {
+			selectors = append(selectors, s)
+		}
+	})
+	return selectors
+}
+
+// metricName returns the metric name of the selector, either written as name
+// or as equality matcher on __name__, empty if it has neither
+func (n *vectorSelector) metricName() string {
+	if n.name != "" {
+		return n.name
+	}
+
+	for _, m := range n.matchers {
+		if m.name == "__name__" && m.op == "=" {
+			return m.value
+		}
+	}
+
+	return ""
+}
+
+// matchesMetric reports if the selector selects series of the metric by name,
+// selectors without any condition on metric name are considered to not select it
+func (n *vectorSelector) matchesMetric(name string) bool {
+	if n.name != "" {
+		return n.name == name
+	}
+
+	found := false
+	for _, m := range n.matchers {
+		if m.name != "__name__" {
+			continue
+		}
+
+		if !m.matches(name) {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
+
+type queryLexer struct {
+	in  string
+	pos int
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || ch == ':' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentChar(ch byte) bool {
+	return isIdentStart(ch) || (ch >= '0' && ch <= '9')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func (l *queryLexer) skipSpace() {
+	for l.pos < len(l.in) {
+		switch {
+		case unicode.IsSpace(rune(l.in[l.pos])):
+			l.pos++
+		case l.in[l.pos] == '#':
+			for l.pos < len(l.in) && l.in[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *queryLexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.in) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	ch := l.in[l.pos]
+	switch {
+	case ch == '$' && l.pos+1 < len(l.in) && l.in[l.pos+1] == '{':
+		// grafana variable in ${var} form
+		end := strings.IndexByte(l.in[l.pos:], '}')
+		if end == -1 {
+			return token{}, fmt.Errorf("unterminated variable at position %d", start)
+		}
+		l.pos += end + 1
+		return token{kind: tokIdent, text: l.in[start:l.pos], pos: start}, nil
+	case isIdentStart(ch):
+		for l.pos < len(l.in) && isIdentChar(l.in[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.in[start:l.pos], pos: start}, nil
+	case isDigit(ch) || (ch == '.' && l.pos+1 < len(l.in) && isDigit(l.in[l.pos+1])):
+		return l.number()
+	case ch == '"' || ch == '\'' || ch == '`':
+		return l.str()
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "!~"} {
+		if strings.HasPrefix(l.in[l.pos:], op) {
+			l.pos += 2
+			return token{kind: tokPunct, text: op, pos: start}, nil
+		}
+	}
+
+	if strings.ContainsRune("(){}[],=<>+-*/%^@:", rune(ch)) {
+		l.pos++
+		return token{kind: tokPunct, text: string(ch), pos: start}, nil
+	}
+
+	return token{}, fmt.Errorf("unexpected character %q at position %d", ch, start)
+}
+
+// number lexes numbers, e.g. 1, 1.5, 1e3, 0x1f, and durations, e.g. 5m, 1h30m
+func (l *queryLexer) number() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.in[l.pos:], "0x") || strings.HasPrefix(l.in[l.pos:], "0X") {
+		l.pos += 2
+		for l.pos < len(l.in) && strings.ContainsRune("0123456789abcdefABCDEF", rune(l.in[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.in[start:l.pos], pos: start}, nil
+	}
+
+	for l.pos < len(l.in) && (isDigit(l.in[l.pos]) || l.in[l.pos] == '.') {
+		l.pos++
+	}
+
+	// exponent, only if followed by digits so that 1e isn't mistaken for it
+	if l.pos < len(l.in) && (l.in[l.pos] == 'e' || l.in[l.pos] == 'E') {
+		p := l.pos + 1
+		if p < len(l.in) && (l.in[p] == '+' || l.in[p] == '-') {
+			p++
+		}
+		if p < len(l.in) && isDigit(l.in[p]) {
+			l.pos = p
+			for l.pos < len(l.in) && isDigit(l.in[l.pos]) {
+				l.pos++
+			}
+			return token{kind: tokNumber, text: l.in[start:l.pos], pos: start}, nil
+		}
+	}
+
+	if l.pos < len(l.in) && strings.ContainsRune("smhdwyi", rune(l.in[l.pos])) {
+		for l.pos < len(l.in) && (isDigit(l.in[l.pos]) || l.in[l.pos] == '.' || strings.ContainsRune("smhdwyi", rune(l.in[l.pos]))) {
+			l.pos++
+		}
+		return token{kind: tokDuration, text: l.in[start:l.pos], pos: start}, nil
+	}
+
+	return token{kind: tokNumber, text: l.in[start:l.pos], pos: start}, nil
+}
+
+func (l *queryLexer) str() (token, error) {
+	start := l.pos
+	quote := l.in[l.pos]
+	l.pos++
+	for l.pos < len(l.in) && l.in[l.pos] != quote {
+		if l.in[l.pos] == '\\' && quote != '`' {
+			l.pos++
+		}
+		l.pos++
+	}
+
+	if l.pos >= len(l.in) {
+		return token{}, fmt.Errorf("unterminated string at position %d", start)
+	}
+	l.pos++
+
+	raw := l.in[start:l.pos]
+	if quote == '\'' {
+		// convert to double quoted string so that escapes are handled by strconv
+		inner := strings.ReplaceAll(raw[1:len(raw)-1], `\'`, `'`)
+		inner = strings.ReplaceAll(inner, `"`, `\"`)
+		raw = `"` + inner + `"`
+	}
+
+	val, err := strconv.Unquote(raw)
+	if err != nil {
+		return token{}, fmt.Errorf("invalid string at position %d: %w", start, err)
+	}
+
+	return token{kind: tokString, text: val, pos: start}, nil
+}
+
+// rawUntil returns text up to the closing bracket, used for ranges which may
+// contain variables like $__rate_interval
+func (l *queryLexer) rawUntil(closing byte) (string, error) {
+	start := l.pos
+	end := strings.IndexByte(l.in[l.pos:], closing)
+	if end == -1 {
+		return "", fmt.Errorf("missing %q for bracket at position %d", closing, start)
+	}
+
+	l.pos += end + 1
+	return strings.TrimSpace(l.in[start : start+end]), nil
+}
+
+type queryParser struct {
+	lex *queryLexer
+	tok token
+}
+
+// parseQuery parses a PromQL or MetricsQL expression
+func parseQuery(q string) (exprNode, error) {
+	p := &queryParser{lex: &queryLexer{in: q}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tok.text, p.tok.pos)
+	}
+
+	return expr, nil
+}
+
+func (p *queryParser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *queryParser) peek() (token, error) {
+	pos := p.lex.pos
+	t, err := p.lex.next()
+	p.lex.pos = pos
+	return t, err
+}
+
+func (p *queryParser) isPunct(text string) bool {
+	return p.tok.kind == tokPunct && p.tok.text == text
+}
+
+func (p *queryParser) isKeyword(word string) bool {
+	return p.tok.kind == tokIdent && strings.EqualFold(p.tok.text, word)
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.isPunct(text) {
+		return fmt.Errorf("expected %q at position %d, found %q", text, p.tok.pos, p.tok.text)
+	}
+	return p.advance()
+}
+
+// binaryOp returns the binary operator at current token, empty if there is none
+func (p *queryParser) binaryOp() string {
+	switch p.tok.kind {
+	case tokPunct:
+		if _, ok := binaryPrecedence[p.tok.text]; ok {
+			return p.tok.text
+		}
+	case tokIdent:
+		op := strings.ToLower(p.tok.text)
+		if _, ok := binaryPrecedence[op]; ok {
+			return op
+		}
+	}
+
+	return ""
+}
+
+func (p *queryParser) parseBinary(minPrec int) (exprNode, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.binaryOp()
+		if op == "" || binaryPrecedence[op] < minPrec {
+			return lhs, nil
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		be := &binaryExpr{op: op, lhs: lhs}
+		if err := p.parseBinaryModifiers(be); err != nil {
+			return nil, err
+		}
+
+		// ^ is right associative
+		next := binaryPrecedence[op] + 1
+		if op == "^" {
+			next = binaryPrecedence[op]
+		}
+
+		be.rhs, err = p.parseBinary(next)
+		if err != nil {
+			return nil, err
+		}
+		lhs = be
+	}
+}
+
+func (p *queryParser) parseBinaryModifiers(be *binaryExpr) error {
+	var err error
+	if p.isKeyword("bool") {
+		be.returnBool = true
+		if err = p.advance(); err != nil {
+			return err
+		}
+	}
+
+	if p.isKeyword("on") || p.isKeyword("ignoring") {
+		be.matching = strings.ToLower(p.tok.text)
+		if err = p.advance(); err != nil {
+			return err
+		}
+		if be.matchLabels, err = p.parseLabelList(); err != nil {
+			return err
+		}
+	}
+
+	if p.isKeyword("group_left") || p.isKeyword("group_right") {
+		be.card = strings.ToLower(p.tok.text)
+		if err = p.advance(); err != nil {
+			return err
+		}
+		// labels to include are optional
+		if p.isPunct("(") {
+			if be.include, err = p.parseLabelList(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *queryParser) parseLabelList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	labels := []string{}
+	for !p.isPunct(")") {
+		if p.tok.kind != tokIdent && p.tok.kind != tokString {
+			return nil, fmt.Errorf("expected label name at position %d, found %q", p.tok.pos, p.tok.text)
+		}
+		labels = append(labels, p.tok.text)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.isPunct(",") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		} else if !p.isPunct(")") {
+			return nil, fmt.Errorf("expected \",\" or \")\" at position %d, found %q", p.tok.pos, p.tok.text)
+		}
+	}
+
+	return labels, p.advance()
+}
+
+func (p *queryParser) parseUnary() (exprNode, error) {
+	if p.isPunct("-") || p.isPunct("+") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		// unary operator binds looser than ^, -a ^ b is -(a ^ b)
+		expr, err := p.parseBinary(binaryPrecedence["^"])
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: op, expr: expr}, nil
+	}
+
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parsePostfix(expr)
+}
+
+// parsePostfix parses range, subquery, offset and @ modifiers following an expression
+func (p *queryParser) parsePostfix(expr exprNode) (exprNode, error) {
+	for {
+		switch {
+		case p.isPunct("["):
+			raw, err := p.lex.rawUntil(']')
+			if err != nil {
+				return nil, err
+			}
+
+			sel, isSelector := expr.(*vectorSelector)
+			if i := strings.IndexByte(raw, ':'); i != -1 {
+				expr = &subqueryExpr{expr: expr, rng: strings.TrimSpace(raw[:i]), step: strings.TrimSpace(raw[i+1:])}
+			} else if isSelector && sel.rng == "" {
+				sel.rng = raw
+			} else {
+				// MetricsQL allows range on any expression, it is an implicit subquery
+				expr = &subqueryExpr{expr: expr, rng: raw}
+			}
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case p.isKeyword("offset"):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			offset := ""
+			if p.isPunct("-") {
+				offset = "-"
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+
+			if p.tok.kind != tokDuration && p.tok.kind != tokNumber && p.tok.kind != tokIdent {
+				return nil, fmt.Errorf("expected duration after offset at position %d", p.tok.pos)
+			}
+			offset += p.tok.text
+
+			if err := setModifier(expr, offset, ""); err != nil {
+				return nil, err
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case p.isPunct("@"):
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			at := p.tok.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			// start() and end()
+			if p.isPunct("(") {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				at += "()"
+			}
+
+			if err := setModifier(expr, "", at); err != nil {
+				return nil, err
+			}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func setModifier(expr exprNode, offset, at string) error {
+	switch n := expr.(type) {
+	case *vectorSelector:
+		if offset != "" {
+			n.offset = offset
+		}
+		if at != "" {
+			n.at = at
+		}
+	case *subqueryExpr:
+		if offset != "" {
+			n.offset = offset
+		}
+		if at != "" {
+			n.at = at
+		}
+	default:
+		return fmt.Errorf("offset and @ modifiers are only supported on selectors and subqueries")
+	}
+
+	return nil
+}
+
+func (p *queryParser) parsePrimary() (exprNode, error) {
+	t := p.tok
+	switch t.kind {
+	case tokNumber, tokDuration:
+		return &numberLiteral{val: t.text}, p.advance()
+	case tokString:
+		return &stringLiteral{val: t.text}, p.advance()
+	case tokPunct:
+		switch t.text {
+		case "(":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			expr, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			return &parenExpr{expr: expr}, p.expect(")")
+		case "{":
+			return p.parseSelector("")
+		}
+	case tokIdent:
+		next, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+
+		lower := strings.ToLower(t.text)
+		isCall := next.kind == tokPunct && next.text == "("
+		if (lower == "inf" || lower == "nan") && !isCall {
+			return &numberLiteral{val: t.text}, p.advance()
+		}
+
+		if lower == "with" && isCall {
+			return nil, fmt.Errorf("MetricsQL WITH templates are not supported, found at position %d", t.pos)
+		}
+
+		isGrouping := next.kind == tokIdent && (strings.EqualFold(next.text, "by") || strings.EqualFold(next.text, "without"))
+		if aggregateOps[lower] && (isCall || isGrouping) {
+			return p.parseAggregate()
+		}
+
+		if isCall {
+			return p.parseCall()
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return p.parseSelector(t.text)
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *queryParser) parseArgs() ([]exprNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	args := []exprNode{}
+	for !p.isPunct(")") {
+		arg, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.isPunct(",") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		} else if !p.isPunct(")") {
+			return nil, fmt.Errorf("expected \",\" or \")\" at position %d, found %q", p.tok.pos, p.tok.text)
+		}
+	}
+
+	return args, p.advance()
+}
+
+func (p *queryParser) parseCall() (exprNode, error) {
+	call := &callExpr{fn: p.tok.text}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if call.args, err = p.parseArgs(); err != nil {
+		return nil, err
+	}
+
+	// grouping after a function turns it into an aggregation, e.g. MetricsQL count_over_time(x) by (job)
+	if p.isKeyword("by") || p.isKeyword("without") {
+		agg := &aggregateExpr{op: call.fn, args: call.args}
+		return agg, p.parseGrouping(agg)
+	}
+
+	if p.isKeyword("keep_metric_names") {
+		call.keepMetricNames = true
+		return call, p.advance()
+	}
+
+	return call, nil
+}
+
+func (p *queryParser) parseAggregate() (exprNode, error) {
+	agg := &aggregateExpr{op: strings.ToLower(p.tok.text)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseGrouping(agg); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if agg.args, err = p.parseArgs(); err != nil {
+		return nil, err
+	}
+
+	if agg.modifier == "" {
+		if err := p.parseGrouping(agg); err != nil {
+			return nil, err
+		}
+	}
+
+	// MetricsQL limit on no. of output series
+	if p.isKeyword("limit") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return agg, p.advance()
+	}
+
+	return agg, nil
+}
+
+func (p *queryParser) parseGrouping(agg *aggregateExpr) error {
+	if !p.isKeyword("by") && !p.isKeyword("without") {
+		return nil
+	}
+
+	agg.modifier = strings.ToLower(p.tok.text)
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	var err error
+	agg.grouping, err = p.parseLabelList()
+	return err
+}
+
+func (p *queryParser) parseSelector(name string) (exprNode, error) {
+	sel := &vectorSelector{name: name}
+	if !p.isPunct("{") {
+		return sel, nil
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	for !p.isPunct("}") {
+		if p.tok.kind != tokIdent && p.tok.kind != tokString {
+			return nil, fmt.Errorf("expected label name at position %d, found %q", p.tok.pos, p.tok.text)
+		}
+
+		// metric name may be written as a bare quoted string, e.g. {"http_requests_total"}
+		label := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.isPunct(",") || p.isPunct("}") {
+			sel.name = label
+		} else {
+			if p.tok.kind != tokPunct || (p.tok.text != "=" && p.tok.text != "!=" && p.tok.text != "=~" && p.tok.text != "!~") {
+				return nil, fmt.Errorf("expected matcher operator at position %d, found %q", p.tok.pos, p.tok.text)
+			}
+
+			op := p.tok.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			if p.tok.kind != tokString {
+				return nil, fmt.Errorf("expected string value at position %d, found %q", p.tok.pos, p.tok.text)
+			}
+
+			m, err := newLabelMatcher(label, op, p.tok.text)
+			if err != nil {
+				return nil, err
+			}
+			sel.matchers = append(sel.matchers, m)
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		// MetricsQL allows "or" between filters, its filters are treated as a single set
+		if p.isPunct(",") || p.isKeyword("or") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		} else if !p.isPunct("}") {
+			return nil, fmt.Errorf("expected \",\" or \"}\" at position %d, found %q", p.tok.pos, p.tok.text)
+		}
+	}
+
+	return sel, p.advance()
+}
@@ -0,0 +1,360 @@
+package mode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQueryString(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"metric", "up", "up"},
+		{"matchers", `up{job="api", env!="dev"}`, `up{job="api", env!="dev"}`},
+		{"regex matchers", `up{job=~"api|web",env!~'dev.*'}`, `up{job=~"api|web", env!~"dev.*"}`},
+		{"name as matcher", `{__name__="up",job="api"}`, `{__name__="up", job="api"}`},
+		{"quoted name", `{"up", job="api"}`, `up{job="api"}`},
+		{"range", "rate(http_requests_total[5m])", "rate(http_requests_total[5m])"},
+		{"offset", "http_requests_total offset 1h", "http_requests_total offset 1h"},
+		{"negative offset", "http_requests_total offset -1h", "http_requests_total offset -1h"},
+		{"at", "http_requests_total @ 1609746000", "http_requests_total @ 1609746000"},
+		{"at end", "http_requests_total @ end()", "http_requests_total @ end()"},
+		{"range offset at", "rate(x[5m] offset 1h @ start())", "rate(x[5m] offset 1h @ start())"},
+		{"subquery", "max_over_time(rate(x[5m])[1h:1m])", "max_over_time(rate(x[5m])[1h:1m])"},
+		{"subquery default step", "max_over_time(rate(x[5m])[1h:])", "max_over_time(rate(x[5m])[1h:])"},
+		{"subquery offset", "rate(x[5m])[1h:1m] offset 1d", "rate(x[5m])[1h:1m] offset 1d"},
+		{"aggregation by", "sum by (job) (rate(x[5m]))", "sum by (job) (rate(x[5m]))"},
+		{"aggregation trailing by", "sum(rate(x[5m])) by (job, instance)", "sum by (job, instance) (rate(x[5m]))"},
+		{"aggregation without", "avg without (instance) (x)", "avg without (instance) (x)"},
+		{"aggregation parameter", "topk(5, sum by (job) (x))", "topk (5, sum by (job) (x))"},
+		{"aggregation upper case", "SUM BY (job) (x)", "sum by (job) (x)"},
+		{"string argument", `count_values("version", build_info)`, `count_values ("version", build_info)`},
+		{"label_replace", `label_replace(up, "dst", "$1", "src", "(.*)")`, `label_replace(up, "dst", "$1", "src", "(.*)")`},
+		{"binary", "a + b * c", "a + b * c"},
+		{"binary bool", "a > bool 1", "a > bool 1"},
+		{"binary on", "a / on (job) b", "a / on (job) b"},
+		{"binary ignoring", "a / ignoring (code) b", "a / ignoring (code) b"},
+		{"group_left", "a * on (job) group_left (version) b", "a * on (job) group_left (version) b"},
+		{"group_right without labels", "a * on (job) group_right b", "a * on (job) group_right () b"},
+		{"set operators", "a and b or c unless d", "a and b or c unless d"},
+		{"parens", "(a + b) / c", "(a + b) / c"},
+		{"unary", "-a ^ 2", "-a ^ 2"},
+		{"numbers", "x * 1e3 + 0x1f - Inf", "x * 1e3 + 0x1f - Inf"},
+		{"comment", "up # all targets\n", "up"},
+		{"grafana variables", `rate(x{job="$job"}[$__rate_interval])`, `rate(x{job="$job"}[$__rate_interval])`},
+		{"metricsql keep_metric_names", "rate(x[5m]) keep_metric_names", "rate(x[5m]) keep_metric_names"},
+		{"metricsql implicit subquery", "max_over_time(rate(x[5m])[1h])", "max_over_time(rate(x[5m])[1h:])"},
+		{"metricsql or filters", `x{job="a" or env="b"}`, `x{job="a", env="b"}`},
+		{"metricsql default", "x default 0", "x default 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+
+			got := expr.String()
+			if got != tt.want {
+				t.Fatalf("parseQuery(%q).String() = %q, want %q", tt.query, got, tt.want)
+			}
+
+			// printed query has to parse back to the same query
+			again, err := parseQuery(got)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) of printed query failed: %v", got, err)
+			}
+			if again.String() != got {
+				t.Fatalf("round trip of %q = %q", got, again.String())
+			}
+		})
+	}
+}
+
+func TestParseQuerySelectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		metrics  []string
+		matchers [][]string
+		ranges   []string
+		offsets  []string
+		ats      []string
+	}{
+		{
+			name:     "instant",
+			query:    `up{job="api"}`,
+			metrics:  []string{"up"},
+			matchers: [][]string{{`job="api"`}},
+			ranges:   []string{""},
+			offsets:  []string{""},
+			ats:      []string{""},
+		},
+		{
+			name:     "name matcher",
+			query:    `{__name__="up", job!~"web.*"}`,
+			metrics:  []string{"up"},
+			matchers: [][]string{{`__name__="up"`, `job!~"web.*"`}},
+			ranges:   []string{""},
+			offsets:  []string{""},
+			ats:      []string{""},
+		},
+		{
+			name:     "ranges offsets and at",
+			query:    `rate(a[5m] offset 1h) / rate(b{code=~"5.."}[1m] @ 100)`,
+			metrics:  []string{"a", "b"},
+			matchers: [][]string{nil, {`code=~"5.."`}},
+			ranges:   []string{"5m", "1m"},
+			offsets:  []string{"1h", ""},
+			ats:      []string{"", "100"},
+		},
+		{
+			name:     "subquery",
+			query:    "max_over_time(rate(x[5m])[1h:1m] offset 1d)",
+			metrics:  []string{"x"},
+			matchers: [][]string{nil},
+			ranges:   []string{"5m"},
+			offsets:  []string{""},
+			ats:      []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+
+			selectors := querySelectors(expr)
+			if len(selectors) != len(tt.metrics) {
+				t.Fatalf("got %d selectors, want %d", len(selectors), len(tt.metrics))
+			}
+
+			for i, s := range selectors {
+				var matchers []string
+				for _, m := range s.matchers {
+					matchers = append(matchers, m.String())
+				}
+
+				if s.metricName() != tt.metrics[i] {
+					t.Errorf("selector %d: metric = %q, want %q", i, s.metricName(), tt.metrics[i])
+				}
+				if !reflect.DeepEqual(matchers, tt.matchers[i]) {
+					t.Errorf("selector %d: matchers = %q, want %q", i, matchers, tt.matchers[i])
+				}
+				if s.rng != tt.ranges[i] {
+					t.Errorf("selector %d: range = %q, want %q", i, s.rng, tt.ranges[i])
+				}
+				if s.offset != tt.offsets[i] {
+					t.Errorf("selector %d: offset = %q, want %q", i, s.offset, tt.offsets[i])
+				}
+				if s.at != tt.ats[i] {
+					t.Errorf("selector %d: at = %q, want %q", i, s.at, tt.ats[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseQuerySubquery(t *testing.T) {
+	expr, err := parseQuery("max_over_time(rate(x[5m])[1h:1m] offset 1d @ end())")
+	if err != nil {
+		t.Fatalf("parseQuery failed: %v", err)
+	}
+
+	call, ok := expr.(*callExpr)
+	if !ok || len(call.args) != 1 {
+		t.Fatalf("expected call with one argument, got %T", expr)
+	}
+
+	sq, ok := call.args[0].(*subqueryExpr)
+	if !ok {
+		t.Fatalf("expected subquery argument, got %T", call.args[0])
+	}
+
+	if sq.rng != "1h" || sq.step != "1m" || sq.offset != "1d" || sq.at != "end()" {
+		t.Fatalf("subquery = [%s:%s] offset %q @ %q", sq.rng, sq.step, sq.offset, sq.at)
+	}
+}
+
+func TestParseQueryAggregation(t *testing.T) {
+	tests := []struct {
+		query    string
+		op       string
+		modifier string
+		grouping []string
+		args     int
+	}{
+		{"sum(x)", "sum", "", nil, 1},
+		{"sum by (job, instance) (x)", "sum", "by", []string{"job", "instance"}, 1},
+		{"sum(x) by (job)", "sum", "by", []string{"job"}, 1},
+		{"count without (instance) (x)", "count", "without", []string{"instance"}, 1},
+		{"topk by (job) (3, x)", "topk", "by", []string{"job"}, 2},
+		{"quantile(0.9, x) without (pod)", "quantile", "without", []string{"pod"}, 2},
+		{"sum by () (x)", "sum", "by", []string{}, 1},
+		{"count_over_time(x[5m]) by (job)", "count_over_time", "by", []string{"job"}, 1},
+		{"topk_max(3, x) limit 10", "topk_max", "", nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+
+			agg, ok := expr.(*aggregateExpr)
+			if !ok {
+				t.Fatalf("expected aggregation, got %T", expr)
+			}
+
+			if agg.op != tt.op || agg.modifier != tt.modifier || len(agg.args) != tt.args {
+				t.Errorf("got %s %s with %d args, want %s %s with %d args", agg.op, agg.modifier, len(agg.args), tt.op, tt.modifier, tt.args)
+			}
+			if !reflect.DeepEqual(agg.grouping, tt.grouping) {
+				t.Errorf("grouping = %q, want %q", agg.grouping, tt.grouping)
+			}
+		})
+	}
+}
+
+func TestParseQueryBinary(t *testing.T) {
+	tests := []struct {
+		query       string
+		op          string
+		returnBool  bool
+		matching    string
+		matchLabels []string
+		card        string
+		include     []string
+	}{
+		{"a + b", "+", false, "", nil, "", nil},
+		{"a == bool b", "==", true, "", nil, "", nil},
+		{"a / on (job, instance) b", "/", false, "on", []string{"job", "instance"}, "", nil},
+		{"a / ignoring (code) b", "/", false, "ignoring", []string{"code"}, "", nil},
+		{"a * on (job) group_left (version, commit) b", "*", false, "on", []string{"job"}, "group_left", []string{"version", "commit"}},
+		{"a * ignoring (pod) group_right b", "*", false, "ignoring", []string{"pod"}, "group_right", nil},
+		{"a AND ON (job) b", "and", false, "on", []string{"job"}, "", nil},
+		{"a unless b", "unless", false, "", nil, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+
+			be, ok := expr.(*binaryExpr)
+			if !ok {
+				t.Fatalf("expected binary expression, got %T", expr)
+			}
+
+			if be.op != tt.op || be.returnBool != tt.returnBool || be.matching != tt.matching || be.card != tt.card {
+				t.Errorf("got op %q bool %v %q %q, want op %q bool %v %q %q",
+					be.op, be.returnBool, be.matching, be.card, tt.op, tt.returnBool, tt.matching, tt.card)
+			}
+			if !reflect.DeepEqual(be.matchLabels, tt.matchLabels) {
+				t.Errorf("matching labels = %q, want %q", be.matchLabels, tt.matchLabels)
+			}
+			if !reflect.DeepEqual(be.include, tt.include) {
+				t.Errorf("included labels = %q, want %q", be.include, tt.include)
+			}
+		})
+	}
+}
+
+func TestParseQueryPrecedence(t *testing.T) {
+	tests := []struct {
+		query string
+		op    string
+		lhs   string
+		rhs   string
+	}{
+		{"a + b * c", "+", "a", "b * c"},
+		{"a * b + c", "+", "a * b", "c"},
+		{"a - b - c", "-", "a - b", "c"},
+		{"a ^ b ^ c", "^", "a", "b ^ c"},
+		{"a or b and c", "or", "a", "b and c"},
+		{"a > b + c", ">", "a", "b + c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+
+			be, ok := expr.(*binaryExpr)
+			if !ok {
+				t.Fatalf("expected binary expression, got %T", expr)
+			}
+
+			if be.op != tt.op || be.lhs.String() != tt.lhs || be.rhs.String() != tt.rhs {
+				t.Errorf("got (%s) %s (%s), want (%s) %s (%s)", be.lhs, be.op, be.rhs, tt.lhs, tt.op, tt.rhs)
+			}
+		})
+	}
+}
+
+func TestParseQueryStringArgs(t *testing.T) {
+	expr, err := parseQuery(`label_join(up, "dst", ",", 'a', "b")`)
+	if err != nil {
+		t.Fatalf("parseQuery failed: %v", err)
+	}
+
+	call, ok := expr.(*callExpr)
+	if !ok {
+		t.Fatalf("expected call, got %T", expr)
+	}
+
+	want := []string{"dst", ",", "a", "b"}
+	if len(call.args) != len(want)+1 {
+		t.Fatalf("got %d args, want %d", len(call.args), len(want)+1)
+	}
+
+	for i, w := range want {
+		s, ok := call.args[i+1].(*stringLiteral)
+		if !ok || s.val != w {
+			t.Errorf("arg %d = %v, want string %q", i+1, call.args[i+1], w)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"empty", "", "unexpected end of query"},
+		{"unterminated string", `up{job="api}`, "unterminated string"},
+		{"unclosed paren", "sum(x", `expected ","`},
+		{"missing matcher value", `up{job=}`, "expected string value"},
+		{"bad matcher operator", `up{job>"a"}`, "expected matcher operator"},
+		{"invalid regex", `up{job=~"("}`, "invalid regex"},
+		{"unclosed range", "rate(x[5m)", `missing ']'`},
+		{"offset on call", "rate(x[5m]) offset 1h", "offset and @ modifiers"},
+		{"trailing tokens", "up up", "unexpected"},
+		{"with template", "WITH (x = up) sum(x)", "WITH templates are not supported"},
+		{"with template lower case", `with (f(m) = rate(m[5m])) f(x)`, "WITH templates are not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQuery(tt.query)
+			if err == nil {
+				t.Fatalf("parseQuery(%q) succeeded, want error containing %q", tt.query, tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("parseQuery(%q) error = %q, want it to contain %q", tt.query, err, tt.err)
+			}
+		})
+	}
+}
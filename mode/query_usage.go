@@ -0,0 +1,72 @@
+package mode
+
+// selectorMetricName returns metric name of a selector passed as metric,
+// e.g. http_request_total{job="api"}, the input itself if it can't be parsed
+func selectorMetricName(metric string) string {
+	expr, err := parseQuery(metric)
+	if err != nil {
+		return metric
+	}
+
+	selectors := querySelectors(expr)
+	if len(selectors) == 0 || selectors[0].metricName() == "" {
+		return metric
+	}
+
+	return selectors[0].metricName()
+}
+
+// queryLabelUsage finds labels a query depends on for the metric: labels in
+// matchers of its selectors, and labels in by, without, on, ignoring, group_left,
+// group_right and label_replace/label_join of expressions enclosing them
+func queryLabelUsage(expr exprNode, metric string) (bool, map[string]bool) {
+	referenced := false
+	labels := map[string]bool{}
+	inspectExpr(expr, func(node exprNode, parents []exprNode) {
+		s, ok := node.(*vectorSelector)
+		if !ok || !s.matchesMetric(metric) {
+			return
+		}
+
+		referenced = true
+		for _, m := range s.matchers {
+			if m.name != "__name__" {
+				labels[m.name] = true
+			}
+		}
+
+		for _, p := range parents {
+			for _, l := range enclosingLabels(p) {
+				labels[l] = true
+			}
+		}
+	})
+
+	return referenced, labels
+}
+
+// enclosingLabels returns labels an expression uses from its sub expressions
+func enclosingLabels(node exprNode) []string {
+	switch n := node.(type) {
+	case *aggregateExpr:
+		return n.grouping
+	case *binaryExpr:
+		return append(append([]string{}, n.matchLabels...), n.include...)
+	case *callExpr:
+		// label_replace(v, dst, replacement, src, regex) and label_join(v, dst, separator, src...)
+		labels := []string{}
+		if (n.fn == "label_replace" || n.fn == "label_join") && len(n.args) > 3 {
+			for _, a := range n.args[3:] {
+				if s, ok := a.(*stringLiteral); ok {
+					labels = append(labels, s.val)
+				}
+				if n.fn == "label_replace" {
+					break
+				}
+			}
+		}
+		return labels
+	}
+
+	return nil
+}
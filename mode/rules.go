@@ -0,0 +1,163 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	alertingRule  = "alerting"
+	recordingRule = "recording"
+)
+
+// ruleInfo is an alerting or recording rule, fetched from the rules API or read from
+// a rule file. Evaluation stats are only known for rules fetched from the API.
+type ruleInfo struct {
+	group          string
+	name           string
+	kind           string
+	expr           string
+	health         string
+	evaluationTime float64
+	lastEvaluation string
+}
+
+// ruleFile is the prometheus and vmalert rule file format
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string     `yaml:"name"`
+	Rules []fileRule `yaml:"rules"`
+}
+
+type fileRule struct {
+	Alert  string `yaml:"alert,omitempty"`
+	Record string `yaml:"record,omitempty"`
+	Expr   string `yaml:"expr"`
+}
+
+func rulesFromAPI(res v1.RulesResult) []ruleInfo {
+	rules := []ruleInfo{}
+	for _, g := range res.Groups {
+		for _, r := range g.Rules {
+			switch v := r.(type) {
+			case v1.AlertingRule:
+				rules = append(rules, ruleInfo{group: g.Name, name: v.Name, kind: alertingRule, expr: v.Query,
+					health: string(v.Health), evaluationTime: v.EvaluationTime, lastEvaluation: v.LastEvaluation.UTC().Format("2006-01-02 15:04:05")})
+			case v1.RecordingRule:
+				rules = append(rules, ruleInfo{group: g.Name, name: v.Name, kind: recordingRule, expr: v.Query,
+					health: string(v.Health), evaluationTime: v.EvaluationTime, lastEvaluation: v.LastEvaluation.UTC().Format("2006-01-02 15:04:05")})
+			}
+		}
+	}
+
+	return rules
+}
+
+// rulesFromFiles reads rule files matching the glob patterns
+func rulesFromFiles(patterns []string) ([]ruleInfo, error) {
+	rules := []ruleInfo{}
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule file pattern %s: %w", pattern, err)
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no rule files found for %s", pattern)
+		}
+
+		for _, f := range files {
+			b, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+
+			var rf ruleFile
+			if err := yaml.Unmarshal(b, &rf); err != nil {
+				return nil, fmt.Errorf("invalid rule file %s: %w", f, err)
+			}
+
+			for _, g := range rf.Groups {
+				for _, r := range g.Rules {
+					ri := ruleInfo{group: g.Name, name: r.Alert, kind: alertingRule, expr: r.Expr}
+					if r.Record != "" {
+						ri.name, ri.kind = r.Record, recordingRule
+					}
+					rules = append(rules, ri)
+				}
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// loadRules reads rules from rule files if provided, otherwise from the rules API
+func loadRules(v1api v1.API, ruleFiles []string) ([]ruleInfo, error) {
+	if len(ruleFiles) != 0 {
+		return rulesFromFiles(ruleFiles)
+	}
+
+	res, err := apiclient.Rules(v1api)
+	if err != nil {
+		return nil, err
+	}
+
+	return rulesFromAPI(res), nil
+}
+
+// ruleLabelUse is a rule referencing a metric along with labels of the metric it uses
+type ruleLabelUse struct {
+	name string
+	used map[string]bool
+	// expression couldn't be parsed, labels are matched by words instead
+	unparsed bool
+	expr     string
+}
+
+// rulesUsingMetric parses every rule once and keeps the ones referencing the metric
+func rulesUsingMetric(rules []ruleInfo, metric string) []ruleLabelUse {
+	uses := []ruleLabelUse{}
+	for _, r := range rules {
+		expr, err := parseQuery(r.expr)
+		if err != nil {
+			// an expression which can't be parsed references the metric if it mentions it
+			if containsWord(r.expr, metric) {
+				uses = append(uses, ruleLabelUse{name: r.name, unparsed: true, expr: r.expr})
+			}
+			continue
+		}
+
+		if referenced, used := queryLabelUsage(expr, metric); referenced {
+			uses = append(uses, ruleLabelUse{name: r.name, used: used})
+		}
+	}
+
+	return uses
+}
+
+// rulesBlockingDrop returns names of rules which use any of the labels, so that
+// dropping the labels breaks them
+func rulesBlockingDrop(uses []ruleLabelUse, labels []string) []string {
+	blocking := []string{}
+	for _, u := range uses {
+		for _, l := range labels {
+			if u.used[l] || (u.unparsed && containsWord(u.expr, l)) {
+				blocking = append(blocking, u.name)
+				break
+			}
+		}
+	}
+
+	return blocking
+}
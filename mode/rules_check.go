@@ -0,0 +1,240 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+type RulesFlag struct {
+	RuleFiles []string
+	Lookback  int
+	DumpAs    string
+}
+
+// seriesCheck checks if any series matching the selectors has the label
+type seriesCheck struct {
+	label string
+	// selectors joined by newline, so that the check can be a map key
+	selectors string
+}
+
+// groupingCheck is a label an aggregation groups by, which has to exist on
+// series of the selectors it aggregates
+type groupingCheck struct {
+	op    string
+	check seriesCheck
+}
+
+type ruleCheck struct {
+	ruleInfo
+	unparsed      bool
+	selectors     []string
+	groupings     []groupingCheck
+	deadSelectors []string
+	missingLabels []string
+}
+
+// producedLabels returns labels created within the expression by label_replace,
+// label_join and count_values, these don't have to exist on the series
+func producedLabels(expr exprNode) map[string]bool {
+	labels := map[string]bool{}
+	inspectExpr(expr, func(node exprNode, _ []exprNode) {
+		switch n := node.(type) {
+		case *callExpr:
+			if (n.fn == "label_replace" || n.fn == "label_join") && len(n.args) > 1 {
+				if s, ok := n.args[1].(*stringLiteral); ok {
+					labels[s.val] = true
+				}
+			}
+		case *aggregateExpr:
+			if n.op == "count_values" && len(n.args) > 0 {
+				if s, ok := n.args[0].(*stringLiteral); ok {
+					labels[s.val] = true
+				}
+			}
+		}
+	})
+
+	return labels
+}
+
+// absentFunctions are meant to match no series, selectors under them aren't checked
+var absentFunctions = map[string]bool{"absent": true, "absent_over_time": true}
+
+func underAbsent(parents []exprNode) bool {
+	for _, p := range parents {
+		if c, ok := p.(*callExpr); ok && absentFunctions[c.fn] {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueSelectors returns selectors of the expression which are expected to match series
+func uniqueSelectors(expr exprNode) []string {
+	seen := map[string]bool{}
+	selectors := []string{}
+	inspectExpr(expr, func(node exprNode, parents []exprNode) {
+		s, ok := node.(*vectorSelector)
+		if !ok || underAbsent(parents) {
+			return
+		}
+
+		sel := plainSelector(s)
+		if !seen[sel] {
+			seen[sel] = true
+			selectors = append(selectors, sel)
+		}
+	})
+
+	return selectors
+}
+
+func newRuleCheck(r ruleInfo) *ruleCheck {
+	rc := &ruleCheck{ruleInfo: r}
+	expr, err := parseQuery(r.expr)
+	if err != nil {
+		rc.unparsed = true
+		return rc
+	}
+
+	rc.selectors = uniqueSelectors(expr)
+	inspectExpr(expr, func(node exprNode, parents []exprNode) {
+		agg, ok := node.(*aggregateExpr)
+		if !ok || agg.modifier != "by" || underAbsent(parents) {
+			return
+		}
+
+		produced := producedLabels(agg)
+		selectors := strings.Join(uniqueSelectors(agg), "\n")
+		for _, l := range agg.grouping {
+			if produced[l] || selectors == "" {
+				continue
+			}
+			rc.groupings = append(rc.groupings, groupingCheck{op: agg.op, check: seriesCheck{label: l, selectors: selectors}})
+		}
+	})
+
+	return rc
+}
+
+// runSeriesChecks finds which checks have atleast one matching series with the label
+func runSeriesChecks(v1api v1.API, checks map[seriesCheck]bool, start, end time.Time) {
+	var (
+		wg   = &sync.WaitGroup{}
+		lock = sync.Mutex{}
+	)
+
+	for c := range checks {
+		wg.Add(1)
+		go func(c seriesCheck) {
+			defer wg.Done()
+			values, err := apiclient.LabelValues(v1api, c.label, strings.Split(c.selectors, "\n"), start, end)
+			if err != nil {
+				fmt.Println("Error faced while finding values of", c.label, "for", c.selectors, ":", err)
+				// a failed check isn't reported as broken
+				values = append(values, "")
+			}
+
+			lock.Lock()
+			checks[c] = len(values) != 0
+			lock.Unlock()
+		}(c)
+	}
+	wg.Wait()
+}
+
+func RulesCheckInvoke(dataSource string, rFlag RulesFlag) {
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	rules, err := loadRules(v1api, rFlag.RuleFiles)
+	if err != nil {
+		fmt.Println("Error faced while loading rules:", err)
+		return
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("No alerting or recording rules found")
+		return
+	}
+
+	checks := map[seriesCheck]bool{}
+	ruleChecks := make([]*ruleCheck, len(rules))
+	for i := range rules {
+		rc := newRuleCheck(rules[i])
+		for _, s := range rc.selectors {
+			checks[seriesCheck{label: "__name__", selectors: s}] = false
+		}
+		for _, g := range rc.groupings {
+			checks[g.check] = false
+		}
+		ruleChecks[i] = rc
+	}
+
+	end := time.Now()
+	runSeriesChecks(v1api, checks, end.Add(-time.Duration(rFlag.Lookback)*time.Second), end)
+
+	broken, unparsed := []*ruleCheck{}, 0
+	for _, rc := range ruleChecks {
+		if rc.unparsed {
+			unparsed++
+			broken = append(broken, rc)
+			continue
+		}
+
+		dead := map[string]bool{}
+		for _, s := range rc.selectors {
+			if !checks[seriesCheck{label: "__name__", selectors: s}] {
+				dead[s] = true
+				rc.deadSelectors = append(rc.deadSelectors, s)
+			}
+		}
+
+		for _, g := range rc.groupings {
+			// labels of aggregations over dead selectors only are already reported
+			alive := false
+			for _, s := range strings.Split(g.check.selectors, "\n") {
+				alive = alive || !dead[s]
+			}
+
+			if alive && !checks[g.check] {
+				rc.missingLabels = append(rc.missingLabels, fmt.Sprintf("%s (%s by)", g.check.label, g.op))
+			}
+		}
+
+		if len(rc.deadSelectors) != 0 || len(rc.missingLabels) != 0 {
+			broken = append(broken, rc)
+		}
+	}
+
+	fmt.Printf("Rules: %d, Broken: %d, Unparsed: %d\n\n", len(rules), len(broken)-unparsed, unparsed)
+	if len(broken) == 0 {
+		fmt.Println("Every selector of every rule matches series in the lookback window")
+		return
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].group != broken[j].group {
+			return broken[i].group < broken[j].group
+		}
+		return broken[i].name < broken[j].name
+	})
+	dumpRuleCheckView(broken, rFlag.DumpAs)
+}
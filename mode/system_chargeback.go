@@ -0,0 +1,321 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const unownedTeam = "unowned"
+
+// OwnerRule maps metrics to a team, a metric is owned if its name matches one of
+// the metric regexes, or its series match one of the jobs or label matchers
+type OwnerRule struct {
+	Team     string   `yaml:"team"`
+	Metrics  []string `yaml:"metrics"`
+	Jobs     []string `yaml:"jobs"`
+	Matchers []string `yaml:"matchers"`
+}
+
+type compiledOwnerRule struct {
+	team     string
+	metrics  []*regexp.Regexp
+	matchers []labelMatcher
+}
+
+// chargeback is series attributed to each team for a date
+type chargeback struct {
+	totalSeries uint64
+	teams       map[string]uint64
+	// series of metrics outside top N which can't be attributed
+	outside uint64
+}
+
+type chargebackRow struct {
+	team        string
+	series      uint64
+	percentage  float64
+	prevSeries  uint64
+	growth      int64
+	growthShare float64
+}
+
+func compileOwnerRules(rules []OwnerRule) ([]compiledOwnerRule, error) {
+	compiled := []compiledOwnerRule{}
+	for _, r := range rules {
+		c := compiledOwnerRule{team: r.Team}
+		for _, m := range r.Metrics {
+			re, err := regexp.Compile(m)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric regex %q for team %s: %w", m, r.Team, err)
+			}
+			c.metrics = append(c.metrics, re)
+		}
+
+		for _, j := range r.Jobs {
+			c.matchers = append(c.matchers, labelMatcher{name: "job", op: "=", value: j})
+		}
+
+		for _, m := range r.Matchers {
+			lm, err := parseMatcher(m)
+			if err != nil {
+				return nil, fmt.Errorf("team %s: %w", r.Team, err)
+			}
+			c.matchers = append(c.matchers, lm)
+		}
+
+		compiled = append(compiled, c)
+	}
+
+	return compiled, nil
+}
+
+// ownsMetric tells if the rule owns the whole metric by its name
+func (r compiledOwnerRule) ownsMetric(name string) bool {
+	for _, re := range r.metrics {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// teamSelector returns a query selecting series of the metric matching any of the
+// matchers of the team, seen within the day before evaluation
+func teamSelector(metric string, matchers []labelMatcher) string {
+	selectors := make([]string, len(matchers))
+	for i, m := range matchers {
+		selectors[i] = fmt.Sprintf("last_over_time(%s{%s}[1d])", metric, m)
+	}
+
+	return strings.Join(selectors, " or ")
+}
+
+// attributeSeries splits series of a metric across teams with one count query per
+// team. Rules are applied in config order and series claimed by earlier teams are
+// excluded, so that every series is attributed atmost once. A team owning the metric
+// by its name takes all series left. Whatever is left at the end goes to unowned.
+func attributeSeries(v1api v1.API, metric string, total uint64, rules []compiledOwnerRule, offset int) map[string]uint64 {
+	owned := map[string]uint64{}
+	remaining := total
+	claimed := []string{}
+	for _, r := range rules {
+		if r.ownsMetric(metric) {
+			if remaining != 0 {
+				owned[r.team] += remaining
+			}
+			return owned
+		}
+
+		if len(r.matchers) == 0 {
+			continue
+		}
+
+		sel := teamSelector(metric, r.matchers)
+		query := "count(" + sel + ")"
+		if len(claimed) != 0 {
+			query = "count((" + sel + ") unless (" + strings.Join(claimed, " or ") + "))"
+		}
+		claimed = append(claimed, sel)
+
+		res, err := apiclient.QueryVector(v1api, query, offset)
+		if err != nil {
+			fmt.Println("Error faced while counting series of", metric, "for team", r.team, ":", err)
+			continue
+		}
+
+		if len(res) == 0 {
+			continue
+		}
+
+		claim := uint64(res[0].Value)
+		// series count of the day and of the query window may differ slightly
+		if claim > remaining {
+			claim = remaining
+		}
+
+		if claim != 0 {
+			owned[r.team] += claim
+			remaining -= claim
+		}
+	}
+
+	if remaining != 0 {
+		owned[unownedTeam] += remaining
+	}
+
+	return owned
+}
+
+// evaluationOffset returns seconds from the end of the date till now, empty date means today
+func evaluationOffset(date string) int {
+	if date == "" {
+		return 0
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0
+	}
+
+	end := day.Add(24 * time.Hour)
+	if end.After(time.Now()) {
+		return 0
+	}
+
+	return int(time.Since(end).Seconds())
+}
+
+func attributeOwners(v1api v1.API, rules []compiledOwnerRule, topN, date string) (chargeback, error) {
+	var (
+		wg     = &sync.WaitGroup{}
+		lock   = sync.Mutex{}
+		cb     = chargeback{teams: map[string]uint64{}}
+		offset = evaluationOffset(date)
+	)
+
+	result, err := apiclient.TopMetrics(v1api, topN, date)
+	if err != nil {
+		return cb, err
+	}
+
+	cb.totalSeries = result.TotalSeries
+	attributed := uint64(0)
+	for _, s := range result.SeriesCountByMetricName {
+		attributed += s.Value
+		wg.Add(1)
+		go func(s v1.Stat) {
+			defer wg.Done()
+			owned := attributeSeries(v1api, s.Name, s.Value, rules, offset)
+			lock.Lock()
+			for team, series := range owned {
+				cb.teams[team] += series
+			}
+			lock.Unlock()
+		}(s)
+	}
+	wg.Wait()
+
+	if cb.totalSeries > attributed {
+		cb.outside = cb.totalSeries - attributed
+	}
+
+	return cb, nil
+}
+
+func chargebackRows(cur, prev chargeback) []chargebackRow {
+	rows := []chargebackRow{}
+	for team, series := range cur.teams {
+		if team == unownedTeam {
+			continue
+		}
+		rows = append(rows, newChargebackRow(team, series, prev.teams[team], cur.totalSeries))
+	}
+
+	// teams which owned series on previous day only
+	for team, series := range prev.teams {
+		if _, ok := cur.teams[team]; !ok && team != unownedTeam {
+			rows = append(rows, newChargebackRow(team, 0, series, cur.totalSeries))
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].series != rows[j].series {
+			return rows[i].series > rows[j].series
+		}
+		return rows[i].team < rows[j].team
+	})
+
+	return rows
+}
+
+func newChargebackRow(team string, series, prevSeries, totalSeries uint64) chargebackRow {
+	r := chargebackRow{team: team, series: series, prevSeries: prevSeries, growth: int64(series) - int64(prevSeries)}
+	if totalSeries != 0 {
+		r.percentage = round2(float64(series) * 100 / float64(totalSeries))
+	}
+
+	if prevSeries != 0 {
+		r.growthShare = round2(float64(r.growth) * 100 / float64(prevSeries))
+	}
+
+	return r
+}
+
+func SystemChargebackInvoke(dataSource string, sFlag SystemFlag) {
+	rules, err := compileOwnerRules(sFlag.Owners)
+	if err != nil {
+		fmt.Println("Error faced while reading owners:", err)
+		return
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("No owners found in config, please add owners section to attribute series to teams")
+		return
+	}
+
+	// today is still partial, so yesterday is compared with the day before by default
+	day := time.Now().UTC().AddDate(0, 0, -1)
+	if sFlag.Date == "" {
+		sFlag.Date = day.Format("2006-01-02")
+	} else {
+		day, err = time.Parse("2006-01-02", sFlag.Date)
+		if err != nil {
+			fmt.Printf("Invalid date %s, format is YYYY-MM-DD\n", sFlag.Date)
+			return
+		}
+	}
+
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	var (
+		wg             = &sync.WaitGroup{}
+		cur, prev      chargeback
+		curErr, prvErr error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		cur, curErr = attributeOwners(v1api, rules, sFlag.TopN, sFlag.Date)
+	}()
+	go func() {
+		defer wg.Done()
+		prev, prvErr = attributeOwners(v1api, rules, sFlag.TopN, day.AddDate(0, 0, -1).Format("2006-01-02"))
+	}()
+	wg.Wait()
+
+	if curErr != nil {
+		fmt.Println("Error faced while attributing series to owners:", curErr)
+		return
+	}
+
+	// growth can't be presented without previous day, shares still are
+	if prvErr != nil {
+		fmt.Println("Error faced while attributing series to owners for previous day:", prvErr)
+	}
+
+	rows := chargebackRows(cur, prev)
+	unowned := newChargebackRow(unownedTeam, cur.teams[unownedTeam], prev.teams[unownedTeam], cur.totalSeries)
+	outside := newChargebackRow("outside top N", cur.outside, prev.outside, cur.totalSeries)
+
+	dumpChargebackView(cur.totalSeries, rows, unowned, outside, sFlag.DumpAs)
+}
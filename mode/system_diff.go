@@ -0,0 +1,124 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	diffNew         = "new"
+	diffDisappeared = "disappeared"
+	diffGrown       = "grown"
+)
+
+type statDiff struct {
+	name   string
+	from   uint64
+	to     uint64
+	change float64
+	kind   string
+}
+
+// diffStats compares stats of two dates. As stats are limited to top N entries, an entry
+// reported as new or disappeared may also have just moved in or out of top N.
+func diffStats(from, to []v1.Stat, growthPercent float64, growthAbsolute uint64) []statDiff {
+	fromMap := map[string]uint64{}
+	for _, s := range from {
+		fromMap[s.Name] = s.Value
+	}
+
+	toMap := map[string]uint64{}
+	for _, s := range to {
+		toMap[s.Name] = s.Value
+	}
+
+	diffs := []statDiff{}
+	for _, s := range to {
+		prev, ok := fromMap[s.Name]
+		if !ok {
+			diffs = append(diffs, statDiff{name: s.Name, to: s.Value, kind: diffNew})
+			continue
+		}
+
+		if s.Value <= prev {
+			continue
+		}
+
+		change := float64(s.Value-prev) * 100 / float64(prev)
+		if change > growthPercent || s.Value-prev > growthAbsolute {
+			diffs = append(diffs, statDiff{name: s.Name, from: prev, to: s.Value, change: round2(change), kind: diffGrown})
+		}
+	}
+
+	for _, s := range from {
+		if _, ok := toMap[s.Name]; !ok {
+			diffs = append(diffs, statDiff{name: s.Name, from: s.Value, kind: diffDisappeared})
+		}
+	}
+
+	// biggest absolute movement first
+	delta := func(d statDiff) uint64 {
+		if d.to > d.from {
+			return d.to - d.from
+		}
+		return d.from - d.to
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return delta(diffs[i]) > delta(diffs[j])
+	})
+
+	return diffs
+}
+
+func SystemDiffInvoke(dataSource string, sFlag SystemFlag) {
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	var (
+		wg             = &sync.WaitGroup{}
+		from, to       v1.TSDBResult
+		fromErr, toErr error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		from, fromErr = apiclient.TopMetrics(v1api, sFlag.TopN, sFlag.From)
+	}()
+	go func() {
+		defer wg.Done()
+		to, toErr = apiclient.TopMetrics(v1api, sFlag.TopN, sFlag.To)
+	}()
+	wg.Wait()
+
+	if fromErr != nil {
+		fmt.Println("Error faced while fetching top metrics for", sFlag.From, ":", fromErr)
+		return
+	}
+
+	if toErr != nil {
+		fmt.Println("Error faced while fetching top metrics for", sFlag.To, ":", toErr)
+		return
+	}
+
+	fmt.Printf("Total Timeseries: %d (%s) -> %d (%s)\n\n", from.TotalSeries, sFlag.From, to.TotalSeries, sFlag.To)
+
+	dumpDiffView("Metric", sFlag.From, sFlag.To,
+		diffStats(from.SeriesCountByMetricName, to.SeriesCountByMetricName, sFlag.GrowthPercent, sFlag.GrowthAbsolute), sFlag.DumpAs)
+	dumpDiffView("Label Value Pair", sFlag.From, sFlag.To,
+		diffStats(from.SeriesCountByLabelValuePair, to.SeriesCountByLabelValuePair, sFlag.GrowthPercent, sFlag.GrowthAbsolute), sFlag.DumpAs)
+}
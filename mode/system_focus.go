@@ -0,0 +1,65 @@
+package mode
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// systemFocusLabel ranks values of the focus label by series across all metrics and
+// drills into the metrics which top values contribute to
+func systemFocusLabel(v1api v1.API, sFlag SystemFlag) {
+	date := sFlag.Cardinality
+	if date == "today" {
+		date = ""
+	}
+
+	r, err := apiclient.MetricInfo(v1api, "", sFlag.FocusLabel, sFlag.TopN, date)
+	if err != nil {
+		fmt.Println("Error faced while fetching series by", sFlag.FocusLabel, ":", err)
+		return
+	}
+
+	dumpStatView(table.Row{sFlag.FocusLabel, "Cardinality", "Cardinality %"}, r.SeriesCountByFocusLabelValue, r.TotalSeries, sFlag.DumpAs)
+
+	values := r.SeriesCountByFocusLabelValue
+	if len(values) > sFlag.DrillDown {
+		values = values[:sFlag.DrillDown]
+	}
+
+	var (
+		wg      = &sync.WaitGroup{}
+		metrics = make([]v1.TSDBWithMetricResult, len(values))
+		failed  = make([]bool, len(values))
+	)
+
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			selector := fmt.Sprintf("{%s=%q}", sFlag.FocusLabel, values[i].Name)
+			res, err := apiclient.MetricInfo(v1api, selector, "", sFlag.TopN, date)
+			if err != nil {
+				fmt.Println("Error faced while fetching metrics for", selector, ":", err)
+				failed[i] = true
+				return
+			}
+			metrics[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range values {
+		if failed[i] {
+			continue
+		}
+
+		fmt.Printf("Metrics contributing to %s=%q\n", sFlag.FocusLabel, values[i].Name)
+		dumpStatView(table.Row{"Metric", "Cardinality", "Cardinality %"}, metrics[i].SeriesCountByMetricName, values[i].Value, sFlag.DumpAs)
+	}
+}
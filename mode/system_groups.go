@@ -0,0 +1,154 @@
+package mode
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// every metric is needed to group, not just the top N
+const groupMetricsTopN = "100000"
+
+// GroupRule puts metrics whose name matches the regex under a custom group
+type GroupRule struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+type compiledGroupRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+type metricGroupInfo struct {
+	name       string
+	series     uint64
+	percentage float64
+	metrics    []metricSeriesCount
+}
+
+func compileGroupRules(rules []GroupRule) ([]compiledGroupRule, error) {
+	compiled := []compiledGroupRule{}
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q for group %s: %w", r.Regex, r.Name, err)
+		}
+		compiled = append(compiled, compiledGroupRule{name: r.Name, re: re})
+	}
+
+	return compiled, nil
+}
+
+// metricPrefix returns the name up to and including the depth-th separator,
+// the whole name is returned if it has fewer separators
+func metricPrefix(name, separators string, depth int) string {
+	seen := 0
+	for i, ch := range name {
+		if strings.ContainsRune(separators, ch) {
+			seen++
+			if seen == depth {
+				return name[:i+1]
+			}
+		}
+	}
+
+	return name
+}
+
+// metricGroup returns the group of a metric, custom rules take precedence over prefix
+func metricGroup(name string, rules []compiledGroupRule, separators string, depth int) string {
+	for _, r := range rules {
+		if r.re.MatchString(name) {
+			return r.name
+		}
+	}
+
+	return metricPrefix(name, separators, depth)
+}
+
+func groupMetrics(result v1.TSDBResult, sFlag SystemFlag) ([]*metricGroupInfo, error) {
+	rules, err := compileGroupRules(sFlag.GroupRules)
+	if err != nil {
+		return nil, err
+	}
+
+	gMap := map[string]*metricGroupInfo{}
+	groups := []*metricGroupInfo{}
+	for _, s := range result.SeriesCountByMetricName {
+		name := metricGroup(s.Name, rules, sFlag.PrefixSeparators, sFlag.PrefixDepth)
+		g, ok := gMap[name]
+		if !ok {
+			g = &metricGroupInfo{name: name}
+			gMap[name] = g
+			groups = append(groups, g)
+		}
+
+		g.series += s.Value
+		g.metrics = append(g.metrics, metricSeriesCount{name: s.Name, series: s.Value})
+	}
+
+	for _, g := range groups {
+		if result.TotalSeries != 0 {
+			g.percentage = round2(float64(g.series) * 100 / float64(result.TotalSeries))
+		}
+
+		// share of a metric is within its group, as drill down presents the group total
+		for i := range g.metrics {
+			if g.series != 0 {
+				g.metrics[i].percentage = round2(float64(g.metrics[i].series) * 100 / float64(g.series))
+			}
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].series > groups[j].series
+	})
+
+	return groups, nil
+}
+
+func systemGroupByPrefix(v1api v1.API, sFlag SystemFlag) {
+	result, err := apiclient.TopMetrics(v1api, groupMetricsTopN, sFlag.Cardinality)
+	if err != nil {
+		fmt.Println("Error faced while fetching metrics:", err)
+		return
+	}
+
+	groups, err := groupMetrics(result, sFlag)
+	if err != nil {
+		fmt.Println("Error faced while grouping metrics:", err)
+		return
+	}
+
+	// series of metrics the backend didn't report can't be grouped
+	grouped := uint64(0)
+	for _, g := range groups {
+		grouped += g.series
+	}
+
+	other := uint64(0)
+	if result.TotalSeries > grouped {
+		other = result.TotalSeries - grouped
+	}
+
+	dumpMetricGroupsView(result.TotalSeries, groups, other, sFlag.DumpAs)
+
+	if sFlag.Group == "" {
+		return
+	}
+
+	for _, g := range groups {
+		if g.name == sFlag.Group {
+			dumpSystemView(g.series, g.metrics, sFlag.DumpAs)
+			return
+		}
+	}
+
+	fmt.Println("No metrics found for group", sFlag.Group)
+}
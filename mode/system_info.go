@@ -4,6 +4,13 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/prometheus/common/model"
 
 	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
 	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
@@ -21,6 +28,32 @@ type SystemFlag struct {
 	ActiveTimeSeries int
 	TopQueries       bool
 	TopNMaxLifeTime  string
+	Days             int
+	Limits           Limits
+	From             string
+	To               string
+	GrowthPercent    float64
+	GrowthAbsolute   uint64
+	Labels           bool
+	LabelPairs       bool
+	Memory           bool
+	Head             bool
+	Backend          string
+	By               string
+	OutlierFactor    float64
+	FocusLabel       string
+	DrillDown        int
+	GroupByPrefix    bool
+	PrefixSeparators string
+	PrefixDepth      int
+	Group            string
+	GroupRules       []GroupRule
+	Date             string
+	Owners           []OwnerRule
+	DashboardsDir    string
+	QueriesTopN      string
+	MinQueryCount    int
+	UselessRatio     float64
 }
 
 type metricSeriesCount struct {
@@ -28,6 +61,41 @@ type metricSeriesCount struct {
 	series     uint64
 	percentage float64
 }
+type systemRate struct {
+	name     string
+	duration int
+	current  float64
+	min      float64
+	avg      float64
+	max      float64
+	// change in % from start to end of the duration
+	trend float64
+}
+
+func newSystemRate(name string, duration int, current float64, values []model.SamplePair) systemRate {
+	r := systemRate{name: name, duration: duration, current: current, min: current, max: current, avg: current}
+	if len(values) == 0 {
+		return r
+	}
+
+	r.min, r.max = math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	for _, v := range values {
+		f := float64(v.Value)
+		sum += f
+		r.min = math.Min(r.min, f)
+		r.max = math.Max(r.max, f)
+	}
+	r.avg = sum / float64(len(values))
+
+	first, last := float64(values[0].Value), float64(values[len(values)-1].Value)
+	if first != 0 {
+		r.trend = (last - first) * 100 / first
+	}
+
+	return r
+}
+
 type systemInfo struct {
 	totalSeries      uint64
 	topMetrics       []metricSeriesCount
@@ -38,6 +106,80 @@ type systemInfo struct {
 	alertRules       int32
 }
 
+// selectorSeries returns no. of series matching the selector. Total series of a
+// selector is reported by VictoriaMetrics only, other backends are queried.
+func selectorSeries(v1api v1.API, backend, selector, date string) (uint64, error) {
+	if backend != apiclient.BackendPrometheus {
+		r, err := apiclient.MetricInfo(v1api, selector, "", "1", date)
+		if err == nil && r.TotalSeries != 0 {
+			return r.TotalSeries, nil
+		}
+	}
+
+	r, err := apiclient.QueryVector(v1api, "count("+selector+")", evaluationOffset(date))
+	if err != nil || len(r) == 0 {
+		return 0, err
+	}
+
+	return uint64(r[0].Value), nil
+}
+
+// labelSeries returns no. of series carrying each label name, most widespread first
+func labelSeries(v1api v1.API, backend, date string) ([]v1.Stat, error) {
+	var (
+		wg     = &sync.WaitGroup{}
+		series = []v1.Stat{}
+		lock   = sync.Mutex{}
+	)
+
+	end := time.Now()
+	if date != "" {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, err
+		}
+		if day.Add(24 * time.Hour).Before(end) {
+			end = day.Add(24 * time.Hour)
+		}
+	}
+
+	names, err := apiclient.LabelNames(v1api, nil, end.Add(-24*time.Hour), end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		// every series has a name
+		if name == "__name__" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(label string) {
+			defer wg.Done()
+			count, err := selectorSeries(v1api, backend, fmt.Sprintf(`{%s!=""}`, label), date)
+			if err != nil {
+				fmt.Println("Error faced while finding series with label", label, ":", err)
+				return
+			}
+
+			lock.Lock()
+			series = append(series, v1.Stat{Name: label, Value: count})
+			lock.Unlock()
+		}(name)
+	}
+	wg.Wait()
+
+	sort.SliceStable(series, func(i, j int) bool {
+		if series[i].Value != series[j].Value {
+			return series[i].Value > series[j].Value
+		}
+		return series[i].Name < series[j].Name
+	})
+
+	return series, nil
+}
+
 func SystemInvoke(dataSource string, sFlag SystemFlag) {
 	// call tsdb api to get top 20 metrics
 	// collect series count
@@ -52,16 +194,21 @@ func SystemInvoke(dataSource string, sFlag SystemFlag) {
 	v1api := v1.NewAPI(client)
 	l := systemInfo{topMetrics: []metricSeriesCount{}}
 
-	if sFlag.Cardinality != "" {
-		if sFlag.Cardinality == "today" {
-			sFlag.Cardinality = ""
-		}
-		result, err := apiclient.TopMetrics(v1api, sFlag.TopN, sFlag.Cardinality)
+	showCardinality := sFlag.Cardinality != ""
+	if sFlag.Cardinality == "today" {
+		sFlag.Cardinality = ""
+	}
+
+	var result v1.TSDBResult
+	if showCardinality || sFlag.Labels || sFlag.LabelPairs || sFlag.Memory || sFlag.Head {
+		result, err = apiclient.TopMetrics(v1api, sFlag.TopN, sFlag.Cardinality)
 		if err != nil {
 			fmt.Println("Error faced while fetching top metrics:", err)
 			return
 		}
+	}
 
+	if showCardinality {
 		l.totalSeries = result.TotalSeries
 		for m := range result.SeriesCountByMetricName {
 			mSeries := result.SeriesCountByMetricName[m].Value
@@ -72,40 +219,153 @@ func SystemInvoke(dataSource string, sFlag SystemFlag) {
 		dumpSystemView(l.totalSeries, l.topMetrics, sFlag.DumpAs)
 	}
 
+	if sFlag.GroupByPrefix {
+		systemGroupByPrefix(v1api, sFlag)
+	}
+
+	if sFlag.Labels {
+		series, err := labelSeries(v1api, sFlag.Backend, sFlag.Cardinality)
+		if err != nil {
+			fmt.Println("Error faced while finding series per label:", err)
+		} else {
+			// prometheus reports series of head only
+			total := result.TotalSeries
+			if total == 0 {
+				total = uint64(result.HeadStats.NumSeries)
+			}
+
+			limit, err := strconv.Atoi(sFlag.TopN)
+			if err == nil && limit < len(series) {
+				series = series[:limit]
+			}
+			dumpStatView(table.Row{"Label", "Series", "Series %"}, series, total, sFlag.DumpAs)
+		}
+	}
+
+	if sFlag.LabelPairs {
+		dumpStatView(table.Row{"Label Value Pair", "Cardinality", "Cardinality %"}, result.SeriesCountByLabelValuePair, result.TotalSeries, sFlag.DumpAs)
+	}
+
+	if sFlag.Memory {
+		totalMemory := uint64(0)
+		for _, s := range result.MemoryInBytesByLabelName {
+			totalMemory += s.Value
+		}
+		dumpStatView(table.Row{"Label", "Memory (bytes)", fmt.Sprintf("Memory %% of top %s", sFlag.TopN)}, result.MemoryInBytesByLabelName, totalMemory, sFlag.DumpAs)
+	}
+
+	// VictoriaMetrics doesn't report head stats
+	if sFlag.Head && result.HeadStats.NumSeries == 0 {
+		fmt.Println("Head stats are not reported by the backend")
+	} else if sFlag.Head {
+		dumpHeadStatsView(result.HeadStats, sFlag.DumpAs)
+	}
+
+	if sFlag.Days > 0 {
+		systemTrend(v1api, sFlag)
+	}
+
+	if sFlag.By != "" {
+		systemBreakdown(v1api, sFlag)
+	}
+
+	if sFlag.FocusLabel != "" {
+		systemFocusLabel(v1api, sFlag)
+	}
+
+	rates := []systemRate{}
 	if sFlag.ChurnRate != 0 {
-		l.churnRate, err = apiclient.SystemChurnRate(v1api, sFlag.Lag)
+		current, trend, err := apiclient.SystemChurnRate(v1api, sFlag.Backend, sFlag.ChurnRate, sFlag.Lag)
 		if err != nil {
 			fmt.Println("Error faced while finding churn rate:", err)
 		} else {
-			fmt.Println("Churn Rate from last", sFlag.ChurnRate, "secs :", l.churnRate)
+			l.churnRate = current
+			rates = append(rates, newSystemRate("Churn Rate (series/s)", sFlag.ChurnRate, current, trend))
 		}
 	}
 
 	if sFlag.IngestionRate != 0 {
-		l.ingestionRate, err = apiclient.SystemIngestionRate(v1api, sFlag.Lag)
+		current, trend, err := apiclient.SystemIngestionRate(v1api, sFlag.Backend, sFlag.IngestionRate, sFlag.Lag)
 		if err != nil {
 			fmt.Println("Error faced while finding ingestion rate:", err)
 		} else {
-			fmt.Println("Ingestion Rate from last 1 hour:", l.ingestionRate)
+			l.ingestionRate = current
+			rates = append(rates, newSystemRate("Ingestion Rate (samples/s)", sFlag.IngestionRate, current, trend))
 		}
 	}
 
 	if sFlag.ActiveTimeSeries != 0 {
-		l.activeTimeSeries, err = apiclient.SystemActiveTimeSeries(v1api, sFlag.Lag)
+		current, trend, err := apiclient.SystemActiveTimeSeries(v1api, sFlag.Backend, sFlag.ActiveTimeSeries, sFlag.Lag)
 		if err != nil {
 			fmt.Println("Error faced while finding active timeseries:", err)
 		} else {
-			fmt.Println("Active timeseries from last 1 hour:", l.activeTimeSeries)
+			l.activeTimeSeries = uint64(current)
+			rates = append(rates, newSystemRate("Active Timeseries", sFlag.ActiveTimeSeries, current, trend))
 		}
 	}
 
+	if len(rates) != 0 {
+		dumpSystemRatesView(rates, sFlag.DumpAs)
+	}
+
 	if sFlag.TopQueries {
-		res, err := apiclient.TopQueries(v1api, sFlag.TopN, sFlag.TopNMaxLifeTime)
-		if err != nil {
-			fmt.Println("Error faced while fetching top queries:", err)
-			return
+		systemTopQueries(v1api, sFlag)
+	}
+}
+
+// systemTrend collects per metric series count of each of the last N days and
+// forecasts when metrics and the whole TSDB cross their limits
+func systemTrend(v1api v1.API, sFlag SystemFlag) {
+	var (
+		wg      = &sync.WaitGroup{}
+		dates   = lastNDates(sFlag.Days)
+		results = make([]v1.TSDBResult, len(dates))
+		failed  = make([]bool, len(dates))
+	)
+
+	for i := range dates {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := apiclient.TopMetrics(v1api, sFlag.TopN, dates[i])
+			if err != nil {
+				fmt.Println("Error faced while fetching top metrics for", dates[i], ":", err)
+				failed[i] = true
+				return
+			}
+			results[i] = r
+		}(i)
+	}
+	wg.Wait()
+
+	total := trendRow{name: "Total Timeseries", counts: make([]float64, len(dates)), limit: sFlag.Limits.TotalSeries}
+	metrics := map[string]*trendRow{}
+	for i := range results {
+		total.counts[i] = float64(results[i].TotalSeries)
+		if failed[i] {
+			total.counts[i] = math.NaN()
 		}
 
-		dumpTopQueriesView(res.TopByAverageDuration, sFlag.DumpAs)
+		for _, s := range results[i].SeriesCountByMetricName {
+			row, ok := metrics[s.Name]
+			if !ok {
+				row = &trendRow{name: s.Name, counts: make([]float64, len(dates)), limit: sFlag.Limits.metricLimit(s.Name)}
+				for j := range row.counts {
+					row.counts[j] = math.NaN()
+				}
+				metrics[s.Name] = row
+			}
+			row.counts[i] = float64(s.Value)
+		}
+	}
+
+	rows := []trendRow{}
+	for _, row := range metrics {
+		row.model = fitGrowth(row.counts)
+		rows = append(rows, *row)
 	}
+	sortTrendRows(rows)
+
+	total.model = fitGrowth(total.counts)
+	dumpTrendView(dates, append([]trendRow{total}, rows...), sFlag.DumpAs)
 }
@@ -0,0 +1,137 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// ruleCost is a rule along with series it reads and, for recording rules, series it records
+type ruleCost struct {
+	ruleInfo
+	inputSeries  uint64
+	outputSeries uint64
+	// output series couldn't be found, ratio isn't known either
+	outputUnknown bool
+	// output series / input series, close to 1 means the aggregation hardly reduces series
+	ratio   float64
+	useless bool
+}
+
+// recordedSeries returns series count of each recording rule, rules whose series
+// couldn't be counted are left out
+func recordedSeries(v1api v1.API, backend string, rules []ruleInfo) map[string]uint64 {
+	var (
+		wg     = &sync.WaitGroup{}
+		lock   = sync.Mutex{}
+		series = map[string]uint64{}
+	)
+
+	names := map[string]bool{}
+	for _, r := range rules {
+		if r.kind == recordingRule {
+			names[r.name] = true
+		}
+	}
+
+	for name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			count, err := selectorSeries(v1api, backend, name, "")
+			if err != nil {
+				fmt.Println("Error faced while finding series of", name, ":", err)
+				return
+			}
+
+			lock.Lock()
+			series[name] = count
+			lock.Unlock()
+		}(name)
+	}
+	wg.Wait()
+
+	return series
+}
+
+func ruleCosts(v1api v1.API, backend string, rules []ruleInfo, uselessRatio float64) []*ruleCost {
+	exprs := make([]string, len(rules))
+	for i := range rules {
+		exprs[i] = rules[i].expr
+	}
+
+	input := querySeries(v1api, exprs)
+	output := recordedSeries(v1api, backend, rules)
+
+	costs := make([]*ruleCost, len(rules))
+	for i, r := range rules {
+		c := &ruleCost{ruleInfo: r, inputSeries: input[r.expr]}
+		if r.kind == recordingRule {
+			out, ok := output[r.name]
+			c.outputSeries, c.outputUnknown = out, !ok
+			if ok && c.inputSeries != 0 {
+				c.ratio = round2(float64(c.outputSeries) / float64(c.inputSeries))
+				c.useless = c.ratio >= uselessRatio
+			}
+		}
+		costs[i] = c
+	}
+
+	sort.SliceStable(costs, func(i, j int) bool {
+		if costs[i].evaluationTime != costs[j].evaluationTime {
+			return costs[i].evaluationTime > costs[j].evaluationTime
+		}
+		return costs[i].outputSeries > costs[j].outputSeries
+	})
+
+	return costs
+}
+
+func SystemRulesInvoke(dataSource string, sFlag SystemFlag) {
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	res, err := apiclient.Rules(v1api)
+	if err != nil {
+		fmt.Println("Error faced while fetching rules:", err)
+		return
+	}
+
+	rules := rulesFromAPI(res)
+	if len(rules) == 0 {
+		fmt.Println("No alerting or recording rules found")
+		return
+	}
+
+	costs := ruleCosts(v1api, sFlag.Backend, rules, sFlag.UselessRatio)
+	dumpRuleCostsView(costs, sFlag.DumpAs)
+
+	evalTime, recorded, useless, unknown := 0.0, uint64(0), 0, 0
+	for _, c := range costs {
+		evalTime += c.evaluationTime
+		recorded += c.outputSeries
+		if c.useless {
+			useless++
+		}
+		if c.outputUnknown {
+			unknown++
+		}
+	}
+	fmt.Printf("Total evaluation time: %vs, series recorded: %d, rules hardly reducing series: %d\n", round2(evalTime), recorded, useless)
+	if unknown != 0 {
+		fmt.Printf("Series recorded by %d rules couldn't be found, they aren't checked for useless aggregation\n", unknown)
+	}
+}
@@ -0,0 +1,348 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// name of the rule group suggested rules are emitted under
+const suggestedRulesGroup = "metric_explorer_suggested_rules"
+
+// functions over counters, the _total suffix is dropped from rule names after them
+var counterFunctions = map[string]bool{"rate": true, "irate": true, "increase": true}
+
+// ruleSuggestion is a recording rule replacing a cluster of top queries which
+// only differ in offsets and matcher values
+type ruleSuggestion struct {
+	name         string
+	expr         string
+	queries      []*topQuery
+	count        float64
+	queryTime    float64
+	inputSeries  uint64
+	outputSeries uint64
+	timeSaved    float64
+}
+
+// topAggregation returns the aggregation the query evaluates to, nil if the
+// query isn't an aggregation, as recording any other query doesn't reduce series
+func topAggregation(expr exprNode) *aggregateExpr {
+	for {
+		switch n := expr.(type) {
+		case *parenExpr:
+			expr = n.expr
+		case *aggregateExpr:
+			return n
+		default:
+			return nil
+		}
+	}
+}
+
+// normalizeQuery returns the query with offsets and matcher values blanked, queries
+// which only differ in these are answered by the same recording rule. Ranges are
+// kept, a rule over 5m doesn't answer a query over 1h.
+func normalizeQuery(q string) (string, error) {
+	expr, err := parseQuery(q)
+	if err != nil {
+		return "", err
+	}
+
+	inspectExpr(expr, func(node exprNode, _ []exprNode) {
+		switch n := node.(type) {
+		case *vectorSelector:
+			n.offset, n.at = "", ""
+			for i := range n.matchers {
+				n.matchers[i].value = ""
+			}
+		case *subqueryExpr:
+			n.offset, n.at = "", ""
+		}
+	})
+
+	return expr.String(), nil
+}
+
+// varyingLabels returns labels of matchers whose value differs across queries of
+// a cluster, queries of a cluster have the same selectors and matchers in the same order
+func varyingLabels(exprs []exprNode) map[string]bool {
+	varying := map[string]bool{}
+	base := querySelectors(exprs[0])
+	for _, e := range exprs[1:] {
+		for i, s := range querySelectors(e) {
+			for j, m := range s.matchers {
+				if m.value != base[i].matchers[j].value {
+					varying[m.name] = true
+				}
+			}
+		}
+	}
+
+	return varying
+}
+
+// generalizeRule drops matchers on varying labels and keeps the labels in the
+// output, so that every query of the cluster can filter the recorded series
+func generalizeRule(expr exprNode, varying map[string]bool) {
+	labels := sortedKeys(varying)
+	inspectExpr(expr, func(node exprNode, _ []exprNode) {
+		switch n := node.(type) {
+		case *vectorSelector:
+			n.offset, n.at = "", ""
+			matchers := []labelMatcher{}
+			for _, m := range n.matchers {
+				if !varying[m.name] {
+					matchers = append(matchers, m)
+				}
+			}
+			n.matchers = matchers
+		case *subqueryExpr:
+			n.offset, n.at = "", ""
+		case *aggregateExpr:
+			if len(labels) == 0 {
+				return
+			}
+
+			switch n.modifier {
+			case "", "by":
+				n.modifier = "by"
+				n.grouping = appendMissing(n.grouping, labels)
+			case "without":
+				grouping := []string{}
+				for _, l := range n.grouping {
+					if !varying[l] {
+						grouping = append(grouping, l)
+					}
+				}
+				n.grouping = grouping
+			}
+		case *binaryExpr:
+			if n.matching == "on" {
+				n.matchLabels = appendMissing(n.matchLabels, labels)
+			}
+		}
+	})
+}
+
+func appendMissing(list []string, values []string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// recordingRuleName names the rule as level:metric:operations, level being the
+// labels the rule aggregates by and operations the ones applied on the first
+// selector, outermost first
+func recordingRuleName(expr exprNode) string {
+	level := ""
+	if agg := topAggregation(expr); agg != nil && agg.modifier == "by" {
+		level = strings.Join(agg.grouping, "_")
+	}
+
+	metric, ops := "", []string{}
+	inspectExpr(expr, func(node exprNode, parents []exprNode) {
+		s, ok := node.(*vectorSelector)
+		if !ok || metric != "" {
+			return
+		}
+
+		metric = s.metricName()
+		for i, p := range parents {
+			switch n := p.(type) {
+			case *aggregateExpr:
+				ops = append(ops, n.op)
+			case *callExpr:
+				op := n.fn
+				if counterFunctions[n.fn] {
+					metric = strings.TrimSuffix(metric, "_total")
+				}
+
+				// range of the argument is part of the operation, e.g. rate5m
+				next := exprNode(s)
+				if i+1 < len(parents) {
+					next = parents[i+1]
+				}
+				switch a := next.(type) {
+				case *vectorSelector:
+					op += a.rng
+				case *subqueryExpr:
+					op += a.rng
+				}
+				ops = append(ops, op)
+			}
+		}
+	})
+
+	return level + ":" + metric + ":" + strings.Join(ops, "_")
+}
+
+// suggestRules clusters top queries by normalized expression and builds a rule
+// for each cluster run atleast minCount times
+func suggestRules(queries []*topQuery, minCount int) ([]*ruleSuggestion, int) {
+	var (
+		clusters = map[string][]*topQuery{}
+		keys     = []string{}
+		skipped  = 0
+	)
+
+	for _, q := range queries {
+		key, err := normalizeQuery(q.query)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		if _, ok := clusters[key]; !ok {
+			keys = append(keys, key)
+		}
+		clusters[key] = append(clusters[key], q)
+	}
+
+	suggestions := []*ruleSuggestion{}
+	names := map[string]int{}
+	for _, key := range keys {
+		members := clusters[key]
+		s := &ruleSuggestion{queries: members}
+		for _, q := range members {
+			s.count += q.count
+			s.queryTime += q.sumDuration
+		}
+
+		if s.count < float64(minCount) {
+			continue
+		}
+
+		// the most frequent query is the base of the rule
+		sort.SliceStable(members, func(i, j int) bool { return members[i].count > members[j].count })
+		exprs := make([]exprNode, len(members))
+		for i, q := range members {
+			exprs[i], _ = parseQuery(q.query)
+		}
+
+		if topAggregation(exprs[0]) == nil {
+			continue
+		}
+
+		generalizeRule(exprs[0], varyingLabels(exprs))
+		s.expr = exprs[0].String()
+		s.name = recordingRuleName(exprs[0])
+
+		// rule names have to be unique
+		names[s.name]++
+		if names[s.name] > 1 {
+			s.name = fmt.Sprintf("%s_%d", s.name, names[s.name])
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, skipped
+}
+
+// estimateRuleSeries finds series read by each rule and series it would record
+func estimateRuleSeries(v1api v1.API, suggestions []*ruleSuggestion) {
+	exprs := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		exprs[i] = s.expr
+	}
+
+	series := querySeries(v1api, exprs)
+	for _, s := range suggestions {
+		s.inputSeries = series[s.expr]
+
+		r, err := apiclient.QueryVector(v1api, "count("+s.expr+")", 0)
+		if err != nil {
+			fmt.Println("Error faced while finding output series of rule", s.name, ":", err)
+			continue
+		}
+
+		if len(r) != 0 {
+			s.outputSeries = uint64(r[0].Value)
+		}
+
+		// queries read the recorded series instead of the input series
+		if s.inputSeries > s.outputSeries {
+			s.timeSaved = round2(s.queryTime * (1 - float64(s.outputSeries)/float64(s.inputSeries)))
+		}
+	}
+}
+
+func suggestedRulesYAML(suggestions []*ruleSuggestion) (string, error) {
+	var rf ruleFile
+	rf.Groups = make([]ruleGroup, 1)
+	rf.Groups[0].Name = suggestedRulesGroup
+	for _, s := range suggestions {
+		rf.Groups[0].Rules = append(rf.Groups[0].Rules, fileRule{Record: s.name, Expr: s.expr})
+	}
+
+	b, err := yaml.Marshal(rf)
+	return string(b), err
+}
+
+func SystemSuggestRulesInvoke(dataSource string, sFlag SystemFlag) {
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	res, err := apiclient.TopQueries(v1api, sFlag.QueriesTopN, sFlag.TopNMaxLifeTime)
+	if err != nil {
+		fmt.Println("Error faced while fetching top queries:", err)
+		return
+	}
+
+	suggestions, skipped := suggestRules(mergeTopQueries(res), sFlag.MinQueryCount)
+	if skipped != 0 {
+		fmt.Printf("%d queries couldn't be parsed and are left out of suggestions\n\n", skipped)
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("No aggregation is run often enough to be worth a recording rule")
+		return
+	}
+
+	estimateRuleSeries(v1api, suggestions)
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].timeSaved > suggestions[j].timeSaved })
+
+	dumpRuleSuggestionsView(suggestions, sFlag.DumpAs)
+
+	saved, newSeries := 0.0, uint64(0)
+	for _, s := range suggestions {
+		saved += s.timeSaved
+		newSeries += s.outputSeries
+	}
+	fmt.Printf("Estimated query time saved: %vs, new series recorded: %d\n\n", round2(saved), newSeries)
+
+	rules, err := suggestedRulesYAML(suggestions)
+	if err != nil {
+		fmt.Println("Error faced while generating rules:", err)
+		return
+	}
+	fmt.Print(rules)
+}
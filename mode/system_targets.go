@@ -0,0 +1,214 @@
+package mode
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/model"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	targetSamplesQuery     = `sum by ( %s ) ( scrape_samples_scraped )`
+	targetSeriesAddedQuery = `sum by ( %s ) ( scrape_series_added )`
+)
+
+type targetInfo struct {
+	group              string
+	job                string
+	instance           string
+	samples            float64
+	seriesAdded        float64
+	health             string
+	scrapeURL          string
+	lastScrapeDuration float64
+	// size of target relative to median size of targets of same job
+	sizeVsMedian float64
+	outlier      bool
+}
+
+type groupInfo struct {
+	name        string
+	series      uint64
+	percentage  float64
+	targets     int
+	samples     float64
+	seriesAdded float64
+}
+
+func targetKey(job, instance string) string {
+	return job + "/" + instance
+}
+
+// markOutliers flags targets which are way larger or smaller than the median
+// target of the same job
+func markOutliers(targets []*targetInfo, factor float64) {
+	byJob := map[string][]*targetInfo{}
+	for _, t := range targets {
+		byJob[t.job] = append(byJob[t.job], t)
+	}
+
+	for _, group := range byJob {
+		sizes := make([]float64, len(group))
+		for i := range group {
+			sizes[i] = group[i].samples
+		}
+		sort.Float64s(sizes)
+
+		median := sizes[len(sizes)/2]
+		if len(sizes)%2 == 0 {
+			median = (sizes[len(sizes)/2-1] + sizes[len(sizes)/2]) / 2
+		}
+
+		if median == 0 {
+			continue
+		}
+
+		for _, t := range group {
+			t.sizeVsMedian = round2(t.samples / median)
+			t.outlier = len(group) > 1 && (t.sizeVsMedian >= factor || t.sizeVsMedian <= 1/factor)
+		}
+	}
+}
+
+// systemBreakdown ranks values of a label, e.g. job, and targets under them by
+// series, samples per scrape and series added per scrape
+func systemBreakdown(v1api v1.API, sFlag SystemFlag) {
+	var (
+		wg                   = &sync.WaitGroup{}
+		focus                v1.TSDBWithMetricResult
+		samples, added       model.Vector
+		targets              v1.TargetsResult
+		focusErr, samplesErr error
+		addedErr, targetsErr error
+	)
+
+	date := sFlag.Cardinality
+	if date == "today" {
+		date = ""
+	}
+
+	groupBy := "job, instance"
+	if sFlag.By != "job" && sFlag.By != "instance" {
+		groupBy = fmt.Sprintf("job, instance, %s", sFlag.By)
+	}
+
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		focus, focusErr = apiclient.MetricInfo(v1api, "", sFlag.By, sFlag.TopN, date)
+	}()
+	go func() {
+		defer wg.Done()
+		samples, samplesErr = apiclient.QueryVector(v1api, fmt.Sprintf(targetSamplesQuery, groupBy), sFlag.Lag)
+	}()
+	go func() {
+		defer wg.Done()
+		added, addedErr = apiclient.QueryVector(v1api, fmt.Sprintf(targetSeriesAddedQuery, groupBy), sFlag.Lag)
+	}()
+	go func() {
+		defer wg.Done()
+		targets, targetsErr = apiclient.Targets(v1api)
+	}()
+	wg.Wait()
+
+	if focusErr != nil {
+		fmt.Println("Error faced while fetching series by", sFlag.By, ":", focusErr)
+		return
+	}
+
+	if samplesErr != nil {
+		fmt.Println("Error faced while fetching samples scraped per target:", samplesErr)
+	}
+
+	if addedErr != nil {
+		fmt.Println("Error faced while fetching series added per target:", addedErr)
+	}
+
+	// target metadata is good to have, stats are still presented without it
+	if targetsErr != nil {
+		fmt.Println("Error faced while fetching targets:", targetsErr)
+	}
+
+	tMap := map[string]*targetInfo{}
+	for _, s := range samples {
+		job, instance := string(s.Metric["job"]), string(s.Metric["instance"])
+		tMap[targetKey(job, instance)] = &targetInfo{
+			group:    string(s.Metric[model.LabelName(sFlag.By)]),
+			job:      job,
+			instance: instance,
+			samples:  float64(s.Value),
+		}
+	}
+
+	for _, s := range added {
+		if t, ok := tMap[targetKey(string(s.Metric["job"]), string(s.Metric["instance"]))]; ok {
+			t.seriesAdded = float64(s.Value)
+		}
+	}
+
+	for _, at := range targets.Active {
+		if t, ok := tMap[targetKey(string(at.Labels["job"]), string(at.Labels["instance"]))]; ok {
+			t.health = string(at.Health)
+			t.scrapeURL = at.ScrapeURL
+			t.lastScrapeDuration = at.LastScrapeDuration
+		}
+	}
+
+	tList := []*targetInfo{}
+	for _, t := range tMap {
+		tList = append(tList, t)
+	}
+	markOutliers(tList, sFlag.OutlierFactor)
+
+	sort.SliceStable(tList, func(i, j int) bool {
+		if tList[i].samples != tList[j].samples {
+			return tList[i].samples > tList[j].samples
+		}
+		return tList[i].seriesAdded > tList[j].seriesAdded
+	})
+
+	gMap := map[string]*groupInfo{}
+	groups := []*groupInfo{}
+	for _, s := range focus.SeriesCountByFocusLabelValue {
+		g := &groupInfo{name: s.Name, series: s.Value}
+		if focus.TotalSeries != 0 {
+			g.percentage = round2(float64(s.Value) * 100 / float64(focus.TotalSeries))
+		}
+		gMap[s.Name] = g
+		groups = append(groups, g)
+	}
+
+	for _, t := range tList {
+		if g, ok := gMap[t.group]; ok {
+			g.targets++
+			g.samples += t.samples
+			g.seriesAdded += t.seriesAdded
+		}
+	}
+
+	limit, err := strconv.Atoi(sFlag.TopN)
+	if err != nil || limit > len(tList) {
+		limit = len(tList)
+	}
+
+	outliers := []*targetInfo{}
+	for _, t := range tList {
+		if t.outlier {
+			outliers = append(outliers, t)
+		}
+	}
+
+	dumpGroupsView(sFlag.By, focus.TotalSeries, groups, sFlag.DumpAs)
+	dumpTargetsView("Targets", sFlag.By, tList[:limit], sFlag.DumpAs)
+	if len(outliers) != 0 {
+		dumpTargetsView(fmt.Sprintf("Targets whose size is %vx away from median size of same %s", sFlag.OutlierFactor, strings.ToLower(sFlag.By)),
+			sFlag.By, outliers, sFlag.DumpAs)
+	}
+}
@@ -0,0 +1,256 @@
+package mode
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+// default lookback of instant selectors
+const defaultLookback = 5 * time.Minute
+
+var durationPartRe = regexp.MustCompile(`(\d+(?:\.\d+)?)(ms|s|m|h|d|w|y)`)
+
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+// parsePromDuration parses durations like 5m, 1h30m and 1.5h
+func parsePromDuration(s string) (time.Duration, error) {
+	parts := durationPartRe.FindAllStringSubmatchIndex(s, -1)
+	if len(parts) == 0 || parts[0][0] != 0 || parts[len(parts)-1][1] != len(s) {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	d := time.Duration(0)
+	for i, p := range parts {
+		// parts must be contiguous, e.g. 1h30m
+		if i > 0 && parts[i-1][1] != p[0] {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		v, err := strconv.ParseFloat(s[p[2]:p[3]], 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(v * float64(durationUnits[s[p[4]:p[5]]]))
+	}
+
+	return d, nil
+}
+
+// selectorCost is a selector of a top query along with series it matches and
+// the window of data it reads
+type selectorCost struct {
+	query    *topQuery
+	selector string
+	metric   string
+	series   uint64
+	lookback time.Duration
+	// weight is series * (lookback + time range of query), query time is split
+	// across selectors of a query in proportion of weight
+	weight float64
+}
+
+type metricQueryCost struct {
+	metric     string
+	queries    int
+	series     uint64
+	queryTime  float64
+	percentage float64
+}
+
+// selectorLookback returns range of the selector, or of the enclosing subquery
+// for instant selectors inside one, default lookback otherwise
+func selectorLookback(s *vectorSelector, parents []exprNode) time.Duration {
+	rng := s.rng
+	for i := len(parents) - 1; rng == "" && i >= 0; i-- {
+		if sq, ok := parents[i].(*subqueryExpr); ok {
+			rng = sq.rng
+		}
+	}
+
+	if d, err := parsePromDuration(rng); err == nil {
+		return d
+	}
+
+	return defaultLookback
+}
+
+// plainSelector returns the selector without range, offset and @ modifiers
+func plainSelector(s *vectorSelector) string {
+	plain := *s
+	plain.rng, plain.offset, plain.at = "", "", ""
+	return plain.String()
+}
+
+func selectorCosts(v1api v1.API, queries []*topQuery) ([]*selectorCost, int) {
+	var (
+		wg       = &sync.WaitGroup{}
+		lock     = sync.Mutex{}
+		costs    = []*selectorCost{}
+		series   = map[string]uint64{}
+		unparsed = 0
+	)
+
+	for _, q := range queries {
+		expr, err := parseQuery(q.query)
+		if err != nil {
+			unparsed++
+			continue
+		}
+
+		inspectExpr(expr, func(node exprNode, parents []exprNode) {
+			s, ok := node.(*vectorSelector)
+			if !ok {
+				return
+			}
+
+			costs = append(costs, &selectorCost{
+				query:    q,
+				selector: plainSelector(s),
+				metric:   s.metricName(),
+				lookback: selectorLookback(s, parents),
+			})
+		})
+	}
+
+	for _, c := range costs {
+		series[c.selector] = 0
+	}
+
+	for sel := range series {
+		wg.Add(1)
+		go func(sel string) {
+			defer wg.Done()
+			r, err := apiclient.MetricInfo(v1api, sel, "", "", "")
+			if err != nil {
+				fmt.Println("Error faced while finding series of selector", sel, ":", err)
+				return
+			}
+
+			lock.Lock()
+			series[sel] = r.TotalSeries
+			lock.Unlock()
+		}(sel)
+	}
+	wg.Wait()
+
+	for _, c := range costs {
+		c.series = series[c.selector]
+		c.weight = float64(c.series) * (c.lookback.Seconds() + c.query.timeRange)
+	}
+
+	return costs, unparsed
+}
+
+// querySeries returns series read by each query, summed over its selectors
+func querySeries(v1api v1.API, queries []string) map[string]uint64 {
+	tq := make([]*topQuery, len(queries))
+	for i := range queries {
+		tq[i] = &topQuery{query: queries[i]}
+	}
+
+	series := map[string]uint64{}
+	costs, _ := selectorCosts(v1api, tq)
+	for _, c := range costs {
+		series[c.query.query] += c.series
+	}
+
+	return series
+}
+
+// metricQueryCosts splits time spent on each query across metrics of its selectors
+func metricQueryCosts(costs []*selectorCost) []*metricQueryCost {
+	totalWeight := map[*topQuery]float64{}
+	for _, c := range costs {
+		totalWeight[c.query] += c.weight
+	}
+
+	byMetric := map[string]*metricQueryCost{}
+	seen := map[string]map[*topQuery]bool{}
+	total := 0.0
+	for _, c := range costs {
+		name := c.metric
+		if name == "" {
+			name = c.selector
+		}
+
+		m, ok := byMetric[name]
+		if !ok {
+			m = &metricQueryCost{metric: name}
+			byMetric[name] = m
+			seen[name] = map[*topQuery]bool{}
+		}
+
+		if !seen[name][c.query] {
+			seen[name][c.query] = true
+			m.queries++
+		}
+
+		if c.series > m.series {
+			m.series = c.series
+		}
+
+		if totalWeight[c.query] != 0 {
+			share := c.query.sumDuration * c.weight / totalWeight[c.query]
+			m.queryTime += share
+			total += share
+		}
+	}
+
+	metrics := []*metricQueryCost{}
+	for _, m := range byMetric {
+		if total != 0 {
+			m.percentage = round2(m.queryTime * 100 / total)
+		}
+		m.queryTime = round2(m.queryTime)
+		metrics = append(metrics, m)
+	}
+
+	sort.SliceStable(metrics, func(i, j int) bool {
+		if metrics[i].queryTime != metrics[j].queryTime {
+			return metrics[i].queryTime > metrics[j].queryTime
+		}
+		return metrics[i].metric < metrics[j].metric
+	})
+
+	return metrics
+}
+
+func systemTopQueries(v1api v1.API, sFlag SystemFlag) {
+	res, err := apiclient.TopQueries(v1api, sFlag.TopN, sFlag.TopNMaxLifeTime)
+	if err != nil {
+		fmt.Println("Error faced while fetching top queries:", err)
+		return
+	}
+
+	dumpTopQueriesView("Top queries by count", res.TopByCount, sFlag.DumpAs)
+	dumpTopQueriesView("Top queries by average duration", res.TopByAverageDuration, sFlag.DumpAs)
+	dumpTopQueriesView("Top queries by sum of duration", res.TopBySumDuration, sFlag.DumpAs)
+
+	costs, unparsed := selectorCosts(v1api, mergeTopQueries(res))
+	if unparsed != 0 {
+		fmt.Printf("%d queries couldn't be parsed and are left out of attribution\n\n", unparsed)
+	}
+
+	if len(costs) == 0 {
+		return
+	}
+
+	dumpSelectorCostView(costs, sFlag.DumpAs)
+	dumpMetricQueryCostView(metricQueryCosts(costs), sFlag.DumpAs)
+}
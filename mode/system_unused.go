@@ -0,0 +1,235 @@
+package mode
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pree-dew/metric-explorer/api_client/client_golang/api"
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+
+	apiclient "github.com/pree-dew/metric-explorer/api_client"
+)
+
+const (
+	sourceTopQueries       = "top queries"
+	sourceRules            = "rules"
+	sourceDashboards       = "dashboards"
+	sourceMetricNamesStats = "metric names stats"
+	// max metric names fetched from metric names stats
+	metricNamesStatsLimit = "100000"
+)
+
+// parsedQuery keeps the raw query along with its expression, expr is nil if
+// the query couldn't be parsed
+type parsedQuery struct {
+	raw  string
+	expr exprNode
+}
+
+type querySource struct {
+	name     string
+	queries  []parsedQuery
+	unparsed int
+}
+
+func newQuerySource(name string, queries []string, parse func(string) (exprNode, error)) querySource {
+	s := querySource{name: name}
+	for _, q := range queries {
+		expr, err := parse(q)
+		if err != nil {
+			s.unparsed++
+		}
+		s.queries = append(s.queries, parsedQuery{raw: q, expr: expr})
+	}
+
+	return s
+}
+
+// topQueryStrings returns queries of all three top query lists
+func topQueryStrings(res v1.TopQueriesResult) []string {
+	queries := []string{}
+	for _, list := range [][]map[string]interface{}{res.TopByCount, res.TopByAverageDuration, res.TopBySumDuration} {
+		for _, q := range list {
+			if s, ok := q["query"].(string); ok {
+				queries = append(queries, s)
+			}
+		}
+	}
+
+	return queries
+}
+
+// ruleQueries returns expressions of alerting and recording rules
+func ruleQueries(res v1.RulesResult) []string {
+	queries := []string{}
+	for _, g := range res.Groups {
+		for _, r := range g.Rules {
+			switch v := r.(type) {
+			case v1.AlertingRule:
+				queries = append(queries, v.Query)
+			case v1.RecordingRule:
+				queries = append(queries, v.Query)
+			}
+		}
+	}
+
+	return queries
+}
+
+// containsWord reports if word is present in s as a whole identifier
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j == -1 {
+			return false
+		}
+
+		start, end := i+j, i+j+len(word)
+		if (start == 0 || !isIdentChar(s[start-1])) && (end == len(s) || !isIdentChar(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// references reports if the query selects the metric, a query which couldn't be
+// parsed is considered to reference every metric whose name appears in it
+func (q parsedQuery) references(metric string) bool {
+	if q.expr == nil {
+		return containsWord(q.raw, metric)
+	}
+
+	for _, s := range querySelectors(q.expr) {
+		if s.matchesMetric(metric) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func SystemUnusedInvoke(dataSource string, sFlag SystemFlag) {
+	client, err := api.NewClient(api.Config{
+		Address: dataSource,
+	})
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+
+	v1api := v1.NewAPI(client)
+
+	var (
+		wg                      = &sync.WaitGroup{}
+		metrics                 v1.TSDBResult
+		topQueries              v1.TopQueriesResult
+		rules                   v1.RulesResult
+		namesStats              v1.MetricNamesStatsResult
+		metricsErr, queriesErr  error
+		rulesErr, namesStatsErr error
+	)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		metrics, metricsErr = apiclient.TopMetrics(v1api, sFlag.TopN, "")
+	}()
+	go func() {
+		defer wg.Done()
+		topQueries, queriesErr = apiclient.TopQueries(v1api, sFlag.QueriesTopN, sFlag.TopNMaxLifeTime)
+	}()
+	go func() {
+		defer wg.Done()
+		rules, rulesErr = apiclient.Rules(v1api)
+	}()
+
+	if sFlag.Backend != apiclient.BackendPrometheus {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			namesStats, namesStatsErr = apiclient.MetricNamesStats(v1api, metricNamesStatsLimit)
+		}()
+	}
+	wg.Wait()
+
+	if metricsErr != nil {
+		fmt.Println("Error faced while fetching top metrics:", metricsErr)
+		return
+	}
+
+	// every source is good to have, unused metrics are still found with the rest
+	sources := []querySource{}
+	if queriesErr != nil {
+		fmt.Println("Error faced while fetching top queries:", queriesErr)
+	} else {
+		sources = append(sources, newQuerySource(sourceTopQueries, topQueryStrings(topQueries), parseQuery))
+	}
+
+	if rulesErr != nil {
+		fmt.Println("Error faced while fetching rules:", rulesErr)
+	} else {
+		sources = append(sources, newQuerySource(sourceRules, ruleQueries(rules), parseQuery))
+	}
+
+	if sFlag.DashboardsDir != "" {
+		dq, err := dashboardQueries(sFlag.DashboardsDir)
+		if err != nil {
+			fmt.Println("Error faced while reading dashboards:", err)
+		} else {
+			queries := make([]string, len(dq))
+			for i := range dq {
+				queries[i] = dq[i].expr
+			}
+			sources = append(sources, newQuerySource(sourceDashboards, queries, parseDashboardQuery))
+		}
+	}
+
+	queried := map[string]bool{}
+	if namesStatsErr != nil {
+		fmt.Println("Error faced while fetching metric names stats:", namesStatsErr)
+	}
+	for _, r := range namesStats.Records {
+		if r.QueryRequestsCount != 0 {
+			queried[r.MetricName] = true
+		}
+	}
+
+	unused := []metricSeriesCount{}
+	unusedSeries := uint64(0)
+	for _, m := range metrics.SeriesCountByMetricName {
+		used := queried[m.Name]
+		for i := 0; i < len(sources) && !used; i++ {
+			for _, q := range sources[i].queries {
+				if q.references(m.Name) {
+					used = true
+					break
+				}
+			}
+		}
+
+		if used {
+			continue
+		}
+
+		percent := 0.0
+		if metrics.TotalSeries != 0 {
+			percent = round2(float64(m.Value) * 100 / float64(metrics.TotalSeries))
+		}
+		unused = append(unused, metricSeriesCount{name: m.Name, series: m.Value, percentage: percent})
+		unusedSeries += m.Value
+	}
+
+	dumpQuerySourcesView(sources, len(namesStats.Records), namesStatsErr == nil && sFlag.Backend != apiclient.BackendPrometheus, sFlag.DumpAs)
+
+	if len(unused) == 0 {
+		fmt.Println("All top metrics are referenced by atleast one source")
+		return
+	}
+
+	dumpSystemView(metrics.TotalSeries, unused, sFlag.DumpAs)
+	if metrics.TotalSeries != 0 {
+		fmt.Printf("Unused series: %d (%v%% of total)\n", unusedSeries, round2(float64(unusedSeries)*100/float64(metrics.TotalSeries)))
+	}
+}
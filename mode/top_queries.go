@@ -0,0 +1,74 @@
+package mode
+
+import (
+	"fmt"
+
+	v1 "github.com/pree-dew/metric-explorer/api_client/client_golang/api/prometheus/v1"
+)
+
+// topQuery is a query merged from all three top query lists, stats missing
+// from the lists it appears in are left 0
+type topQuery struct {
+	query       string
+	timeRange   float64
+	count       float64
+	avgDuration float64
+	sumDuration float64
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	}
+
+	return 0
+}
+
+// mergeTopQueries merges top queries by count, average duration and sum of duration,
+// keeping the order in which queries first appear
+func mergeTopQueries(res v1.TopQueriesResult) []*topQuery {
+	queries := []*topQuery{}
+	byKey := map[string]*topQuery{}
+	for _, list := range [][]map[string]interface{}{res.TopByCount, res.TopByAverageDuration, res.TopBySumDuration} {
+		for _, q := range list {
+			query, ok := q["query"].(string)
+			if !ok {
+				continue
+			}
+
+			// same query over different time ranges is a different entry
+			key := query + "|" + fmt.Sprint(q["timeRangeSeconds"])
+			tq, ok := byKey[key]
+			if !ok {
+				tq = &topQuery{query: query, timeRange: toFloat(q["timeRangeSeconds"])}
+				byKey[key] = tq
+				queries = append(queries, tq)
+			}
+
+			if v, ok := q["count"]; ok {
+				tq.count = toFloat(v)
+			}
+			if v, ok := q["avgDurationSeconds"]; ok {
+				tq.avgDuration = toFloat(v)
+			}
+			if v, ok := q["sumDurationSeconds"]; ok {
+				tq.sumDuration = toFloat(v)
+			}
+		}
+	}
+
+	// derive what is missing, sum = avg * count
+	for _, q := range queries {
+		if q.sumDuration == 0 && q.count != 0 {
+			q.sumDuration = q.avgDuration * q.count
+		}
+		if q.avgDuration == 0 && q.count != 0 {
+			q.avgDuration = q.sumDuration / q.count
+		}
+	}
+
+	return queries
+}
@@ -0,0 +1,52 @@
+package mode
+
+import (
+	"fmt"
+	"sort"
+)
+
+type UsageFlag struct {
+	Dir    string
+	DumpAs string
+}
+
+type dashboardMetricRow struct {
+	metric     string
+	dashboards int
+	labels     []string
+}
+
+func DashboardUsageInvoke(uFlag UsageFlag) {
+	queries, err := dashboardQueries(uFlag.Dir)
+	if err != nil {
+		fmt.Println("Error while reading dashboards:", err)
+		return
+	}
+
+	if len(queries) == 0 {
+		fmt.Println("No queries found in dashboards under", uFlag.Dir)
+		return
+	}
+
+	idx := buildDashboardIndex(queries)
+	rows := []dashboardMetricRow{}
+	for m, u := range idx.metrics {
+		labels := make([]string, 0, len(u.labels))
+		for l := range u.labels {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		rows = append(rows, dashboardMetricRow{metric: m, dashboards: len(u.dashboards), labels: labels})
+	}
+
+	// most widely used metrics first
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].dashboards != rows[j].dashboards {
+			return rows[i].dashboards > rows[j].dashboards
+		}
+		return rows[i].metric < rows[j].metric
+	})
+
+	fmt.Printf("Queries: %d, Unparsed: %d\n\n", idx.queries, idx.unparsed)
+	dumpDashboardUsageView(rows, uFlag.DumpAs)
+}